@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path"
@@ -18,20 +19,48 @@ type Program struct {
 	Name           string
 	Description    string
 	Exe            string
-	Execs          map[string]string
+	Execs          map[string]string  // Plugin command name to executable path
+	PluginsEnabled bool               // Discover `<exe>-<name>` plugin commands
+	PluginDirs     []string           // Directories searched for plugins before $PATH
+	Prompt         string             // Prompt displayed by Shell()
+	Encoders       map[string]Encoder // Custom output formats for output actions
 	Args           []string
 	Commands       map[string]*Command
 	Options        map[string]*Option
 	Topics         map[string]*Topic
 	RunningCommand *exec.Cmd
 
+	// Built in options (nil when the program defines the flags itself)
+	color     *Option
+	logFormat *Option
+	noPager   *Option
+
+	// Set when Exit was called during ParseArgs (a replaced Exit may return)
+	exited bool
+	// Index of the first argument (the command) in the last ParseOptions argv
+	commandIndex int
+
 	// Terminal attached to this program
 	Terminal *Terminal
+
+	// Standard streams used for all program and terminal input and output.
+	// They default to the os streams and may be redirected to buffers, files
+	// or network connections when embedding the program.
+	Out io.Writer
+	Err io.Writer
+	In  io.Reader
+
+	// Exit terminates the program with the provided status code. It defaults
+	// to os.Exit and may be replaced when embedding or testing the program.
+	// A replacement should not return (panic or runtime.Goexit to unwind);
+	// if it does, ParseArgs stops at the error or help that called it and
+	// returns nil without running any action.
+	Exit func(code int)
 }
 
 // New creates a new command line program.
 func New() *Program {
-	program := &Program{Commands: map[string]*Command{}, Options: map[string]*Option{}, Topics: map[string]*Topic{}}
+	program := &Program{Commands: map[string]*Command{}, Options: map[string]*Option{}, Topics: map[string]*Topic{}, Out: os.Stdout, Err: os.Stderr, In: os.Stdin, Exit: os.Exit}
 	program.Terminal = NewTerminal(program)
 	return program
 }
@@ -74,7 +103,7 @@ func (p *Program) SetVersion(version string, command ...string) *Program {
 		if p.Name != "" {
 			name = p.Name
 		}
-		fmt.Printf("%s -- v %s\n\n", name, p.Version)
+		fmt.Fprintf(p.stdout(), "%s -- v %s\n\n", name, p.Version)
 	})
 	return p
 }
@@ -118,18 +147,40 @@ func (p *Program) ParseArgs(argv []string) *Command {
 	// Add implicit help command if there isn't one set
 	if _, ok := p.Commands["help"]; !ok {
 		helpCommand := NewCommand(p, "help [cmd]", "display help for [cmd]")
+		helpCommand.Option("--tree", "display the commands, options and topics as a tree")
+		helpCommand.Option("-s, --search <term>", "search commands, options and topics for <term>")
 		helpCommand.SetAction(HelpAction)
 		p.Commands["help"] = helpCommand
 	}
 
+	p.implicitOptions()
+	p.exited = false
+
 	// Binary name
 	p.Exe = path.Base(argv[0])
 
+	// external sub-commands
+	if p.PluginsEnabled {
+		p.discoverPlugins(argv[0])
+	}
+
 	// process argv
-	args, unknown := p.ParseOptions(Normalize(argv[1:]))
+	normalized, sources := p.normalizeArgs(argv[1:])
+	args, unknown := p.ParseOptions(normalized)
 	p.Args = args
+	if p.exited {
+		return nil
+	}
+	if !p.validOption(p.color, "true", ColorAuto, ColorAlways, ColorNever) ||
+		!p.validOption(p.logFormat, LogFormatText, LogFormatJSON, LogFormatLogfmt) {
+		return nil
+	}
 
 	result := p.ParseNormalizedArgs(p.Args, unknown)
+	if result == nil && p.exited {
+		// Usage error or help already reported
+		return nil
+	}
 
 	// executable sub-commands
 	if result == nil {
@@ -143,61 +194,111 @@ func (p *Program) ParseArgs(argv []string) *Command {
 		}
 	} else {
 		if _, ok := p.Execs[result.Command]; ok {
-			return p.executeSubCommand(argv, args, unknown)
+			p.executeSubCommand(result, pluginArgs(argv[1:], sources, p.commandIndex))
 		}
 	}
 
 	return result
 }
 
-// Execute a sub-command executable.
-func (p *Program) executeSubCommand(argv, args, unknown []string) (cmd *Command) {
-	args = append(args, unknown...)
+// implicitOptions adds the built in global options the program hasn't
+// defined itself.
+func (p *Program) implicitOptions() {
+	// Add implicit verbosity options for the parts not already in use
+	p.implicitOption("-v", "--verbose", "", "increase output verbosity (repeat for more)")
+	p.implicitOption("-q", "--quiet", "", "decrease output verbosity (repeat for less)")
 
-	if len(args) == 0 {
-		p.Help()
+	// Add implicit global options unless the program defines its own
+	if p.color == nil {
+		p.color = p.implicitOption("", "--color", "[=<when>]", "colorize output: auto, always or never", ColorAuto)
 	}
-
-	if "help" == args[0] && 1 == len(args) {
-		p.Help()
+	if p.logFormat == nil {
+		p.logFormat = p.implicitOption("", "--log-format", " <format>", "log output format: text, json or logfmt", LogFormatText)
 	}
-
-	// <cmd> --help
-	if "help" == args[0] {
-		args[0] = args[1]
-		args[1] = "--help"
+	if p.noPager == nil {
+		p.noPager = p.implicitOption("", "--no-pager", "", "do not pipe long output into a pager")
 	}
+}
 
-	// executable
-	dir := path.Dir(argv[1])
-	bin := path.Base(argv[1]) + "-" + args[0]
-
-	// check for ./<bin> first
-	local := path.Join(dir, bin)
+// implicitOption registers a built in option using whichever of the `short`
+// and `long` flags the program hasn't already defined, followed by `value`
+// (e.g. " <format>"). Returns the option or nil if both flags are in use.
+func (p *Program) implicitOption(short, long, value, description string, defaultValue ...string) *Option {
+	var flags []string
+	if short != "" && p.OptionFor(short) == nil {
+		flags = append(flags, short)
+	}
+	if p.OptionFor(long) == nil {
+		flags = append(flags, long)
+	}
+	if len(flags) == 0 {
+		return nil
+	}
+	name := strings.Join(flags, ", ") + value
+	p.Option(name, description, defaultValue...)
+	return p.Options[name]
+}
 
-	// run it
-	args = args[1:]
-	proc := exec.Command(local, args...)
-	proc.Stdout = os.Stdout
-	proc.Stderr = os.Stderr
-	proc.Stdin = os.Stdin
-	if err := proc.Run(); err != nil {
-		/*
-		   	if (err.code == "ENOENT") {
-		     		console.error("\n  %s(1) does not exist, try --help\n", bin)
-		   	} else if (err.code == "EACCES") {
-		     		console.error("\n  %s(1) not executable. try chmod or run with root\n", bin)
-		   	}
-		*/
-		// Print the error for now
-		fmt.Fprintf(os.Stderr, "%v\n", err)
-		os.Exit(1)
+// parseInlineOptions sets the value of inline options (see Option.Inline)
+// passed as `--flag=value` in `argv` and returns the remaining arguments.
+func (p *Program) parseInlineOptions(argv []string) (args []string) {
+	for i, arg := range argv {
+		if arg == "--" {
+			return append(args, argv[i:]...)
+		}
+		if index := strings.Index(arg, "="); index > 0 && strings.HasPrefix(arg, "--") {
+			if option := p.OptionFor(arg[:index]); option != nil && option.Inline {
+				option.Count++
+				option.Value = arg[index+1:]
+				continue
+			}
+		}
+		args = append(args, arg)
 	}
+	return args
+}
 
-	p.RunningCommand = proc
+// normalizeArgs applies parseInlineOptions and Normalize to `argv`, also
+// returning the index in `argv` each normalized argument came from.
+func (p *Program) normalizeArgs(argv []string) (normalized []string, sources []int) {
+	literal := false
+	for i, arg := range argv {
+		parts := []string{arg}
+		if arg == "--" {
+			literal = true
+		} else if !literal {
+			parts = p.parseInlineOptions(parts)
+		}
+		for _, part := range Normalize(parts) {
+			normalized = append(normalized, part)
+			sources = append(sources, i)
+		}
+	}
 	return
 }
 
+// validOption returns true if `option` is unset, nil or has one of the
+// `valid` values, otherwise reports a usage error.
+func (p *Program) validOption(option *Option, valid ...string) bool {
+	if option == nil || option.Value == "" {
+		return true
+	}
+	for _, value := range valid {
+		if option.Value == value {
+			return true
+		}
+	}
+	var expected []string
+	for _, value := range valid {
+		if value != "true" {
+			expected = append(expected, value)
+		}
+	}
+	fmt.Fprintf(p.stderr(), "\n  error: option `%s` has invalid value `%s`, expected one of: %s\n\n", option.Flags, option.Value, strings.Join(expected, ", "))
+	p.exit(1)
+	return false
+}
+
 // Normalize `args`, splitting joined short flags. For example
 // the arg "-abc" is equivalent to "-a -b -c".
 // This also normalizes equal sign and splits "--abc=def" into "--abc def".
@@ -234,6 +335,9 @@ func (p *Program) ParseNormalizedArgs(args, unknown []string) (command *Command)
 		}
 	} else {
 		p.outputHelpIfNecessary("", unknown)
+		if p.exited {
+			return
+		}
 
 		// If there were no args and we have unknown options,
 		// then they are extraneous and we need to error.
@@ -244,6 +348,10 @@ func (p *Program) ParseNormalizedArgs(args, unknown []string) (command *Command)
 	}
 	// Set up the remaining command args
 	if command != nil {
+		if command.IsPlugin() {
+			// Plugins check the raw arguments they are run with (see pluginArgs)
+			return
+		}
 		args = args[1:]
 		for _, arg := range command.Args {
 			if len(args) > 0 {
@@ -253,10 +361,22 @@ func (p *Program) ParseNormalizedArgs(args, unknown []string) (command *Command)
 				// We ran out of arguments, check if we are missing a requirement
 				if arg.Required {
 					p.missingArgument(arg.Name)
+					return nil
 				}
 			}
 		}
-		if command.Action != nil {
+		unknown = command.parseOptions(unknown)
+		if p.exited {
+			return nil
+		}
+		if command.Action != nil && command.Pager {
+			p.Terminal.Page(func(w io.Writer) {
+				out := p.Out
+				defer func() { p.Out = out }()
+				p.Out = w
+				command.Action(p, command, unknown)
+			})
+		} else if command.Action != nil {
 			command.Action(p, command, unknown)
 		}
 	}
@@ -265,6 +385,9 @@ func (p *Program) ParseNormalizedArgs(args, unknown []string) (command *Command)
 
 // OptionFor returns an option matching `arg` if any.
 func (p *Program) OptionFor(arg string) *Option {
+	if arg == "" {
+		return nil
+	}
 	for _, option := range p.Options {
 		if option.Short == arg || option.Long == arg {
 			return option
@@ -276,6 +399,7 @@ func (p *Program) OptionFor(arg string) *Option {
 // ParseOptions parses options from `argv` returning `argv` void of these options.
 func (p *Program) ParseOptions(argv []string) (args, unknownOptions []string) {
 	literal := false
+	p.commandIndex = -1
 
 	// parse options
 	for i := 0; i < len(argv); i++ {
@@ -286,6 +410,9 @@ func (p *Program) ParseOptions(argv []string) (args, unknownOptions []string) {
 			continue
 		}
 		if literal {
+			if len(args) == 0 {
+				p.commandIndex = i
+			}
 			args = append(args, arg)
 			continue
 		}
@@ -294,14 +421,17 @@ func (p *Program) ParseOptions(argv []string) (args, unknownOptions []string) {
 
 		// option is defined
 		if option != nil {
+			option.Count++
 			if option.Required { // requires arg
 				i++
-				if len(argv) < i {
+				if i >= len(argv) {
 					p.optionMissingArgument(option, "")
+					return
 				}
 				arg = argv[i]
 				if "-" == arg[0:1] && "-" != arg {
 					p.optionMissingArgument(option, arg)
+					return
 				}
 				option.Value = arg
 			} else if option.Optional { // optional arg
@@ -334,6 +464,9 @@ func (p *Program) ParseOptions(argv []string) (args, unknownOptions []string) {
 			continue
 		}
 		// arg
+		if len(args) == 0 {
+			p.commandIndex = i
+		}
 		args = append(args, arg)
 	}
 	return
@@ -341,30 +474,30 @@ func (p *Program) ParseOptions(argv []string) (args, unknownOptions []string) {
 
 // Argument `name` is missing.
 func (p *Program) missingArgument(name string) {
-	fmt.Fprintf(os.Stderr, "\n  error: missing required argument `%s`\n\n", name)
-	os.Exit(1)
+	fmt.Fprintf(p.stderr(), "\n  error: missing required argument `%s`\n\n", name)
+	p.exit(1)
 }
 
 // `Option` is missing an argument, but received `flag` or nothing.
 func (p *Program) optionMissingArgument(option *Option, flag string) {
 	if flag != "" {
-		fmt.Fprintf(os.Stderr, "\n  error: option `%s` argument missing, got `%s`\n\n", option.Flags, flag)
+		fmt.Fprintf(p.stderr(), "\n  error: option `%s` argument missing, got `%s`\n\n", option.Flags, flag)
 	} else {
-		fmt.Fprintf(os.Stderr, "\n  error: option `%s` argument missing\n\n", option.Flags)
+		fmt.Fprintf(p.stderr(), "\n  error: option `%s` argument missing\n\n", option.Flags)
 	}
-	os.Exit(1)
+	p.exit(1)
 }
 
 // Unknown command argument
 func (p *Program) unknownArgument(cmd, arg string) {
-	fmt.Fprintf(os.Stderr, "\n  error: command `%s` has unknown argument `%s`\n\n", cmd, arg)
-	os.Exit(1)
+	fmt.Fprintf(p.stderr(), "\n  error: command `%s` has unknown argument `%s`\n\n", cmd, arg)
+	p.exit(1)
 }
 
 // Unknown option `flag`.
 func (p *Program) unknownOption(flag string) {
-	fmt.Fprintf(os.Stderr, "\n  error: unknown option `%s`\n\n", flag)
-	os.Exit(1)
+	fmt.Fprintf(p.stderr(), "\n  error: unknown option `%s`\n\n", flag)
+	p.exit(1)
 }
 
 // outputHelpIfNecessary but only if necessary
@@ -372,6 +505,7 @@ func (p *Program) outputHelpIfNecessary(cmd string, options []string) {
 	for _, option := range options {
 		if option == "--help" || option == "-h" {
 			p.Help()
+			return
 		}
 	}
 }
@@ -388,7 +522,55 @@ func (p *Program) PrintHelp() {
 // Help displays help message and exits.
 func (p *Program) Help() {
 	p.PrintHelp()
-	os.Exit(0)
+	p.exit(0)
+}
+
+// exit terminates the program using the configured Exit function, falling
+// back to os.Exit when there is no program or no Exit function set.
+func (p *Program) exit(code int) {
+	if p != nil && p.Terminal != nil {
+		p.Terminal.mu.Lock()
+		screen, paging := p.Terminal.screen, p.Terminal.paging
+		p.Terminal.mu.Unlock()
+		p.Terminal.stopSpinner()
+		if screen != nil {
+			screen.Close()
+		}
+		if paging != nil {
+			// Show the output written so far before exiting
+			paging.Close()
+		}
+	}
+	if p != nil && p.Exit != nil {
+		p.exited = true
+		p.Exit(code)
+		return
+	}
+	os.Exit(code)
+}
+
+// stdout returns the program output stream (os.Stdout if not set).
+func (p *Program) stdout() io.Writer {
+	if p != nil && p.Out != nil {
+		return p.Out
+	}
+	return os.Stdout
+}
+
+// stderr returns the program error stream (os.Stderr if not set).
+func (p *Program) stderr() io.Writer {
+	if p != nil && p.Err != nil {
+		return p.Err
+	}
+	return os.Stderr
+}
+
+// stdin returns the program input stream (os.Stdin if not set).
+func (p *Program) stdin() io.Reader {
+	if p != nil && p.In != nil {
+		return p.In
+	}
+	return os.Stdin
 }
 
 // -----------------------------------------------------------------------
@@ -408,7 +590,10 @@ func NewCommand(program *Program, command, description string) *Command {
 }
 
 // CommandAction is implemented by any function wanting to be called when
-// a command is selected on the command line.
+// a command is selected on the command line. Values of the command's own
+// options are set on `command.Options`; `unknownArgs` holds the options the
+// program doesn't define as they were given, including the command's own
+// options and their values.
 type CommandAction func(program *Program, command *Command, unknownArgs []string)
 
 // Command captures information about a cli command that the user wishes
@@ -421,9 +606,14 @@ type Command struct {
 	Flags       string
 	Description string
 	Body        string
+	Markdown    bool // Body is rendered as Markdown by help
 	Args        []*Arg
 	Options     []*Option
 	Action      CommandAction
+	Pager       bool // Display output through a pager (see Terminal.Page)
+
+	described bool    // Plugin metadata has been requested
+	output    *Option // --output option added by SetOutputAction (if any)
 }
 
 // Option captures information about a cli option (denoted by a `-` or long `--`
@@ -436,6 +626,9 @@ func (c *Command) Option(flags, description string, defaultValue ...string) *Com
 
 // OptionFor returns an option matching `name` if any.
 func (c *Command) OptionFor(name string) *Option {
+	if name == "" {
+		return nil
+	}
 	for _, option := range c.Options {
 		if option.Short == name || option.Long == name {
 			return option
@@ -444,6 +637,41 @@ func (c *Command) OptionFor(name string) *Option {
 	return nil
 }
 
+// parseOptions sets the values of command options found in `argv`. The
+// arguments are returned unchanged, so actions that read their own options
+// from the unknown args keep working. Returns nil after a usage error.
+func (c *Command) parseOptions(argv []string) []string {
+	for i := 0; i < len(argv); i++ {
+		option := c.OptionFor(argv[i])
+		if option == nil {
+			continue
+		}
+		option.Count++
+		if option.Required || option.Optional {
+			if i+1 < len(argv) && (argv[i+1] == "-" || !strings.HasPrefix(argv[i+1], "-")) {
+				i++
+				option.Value = argv[i]
+				continue
+			}
+			if option.Required {
+				c.Program.optionMissingArgument(option, "")
+				return nil
+			}
+		}
+		option.Value = "true"
+	}
+	return argv
+}
+
+// optionOf returns the option of `command` matching `name` if any (nil if
+// command is nil).
+func optionOf(command *Command, name string) *Option {
+	if command == nil {
+		return nil
+	}
+	return command.OptionFor(name)
+}
+
 // ArgFor returns an arg matching `name` if any.
 func (c *Command) ArgFor(name string) *Arg {
 	for _, arg := range c.Args {
@@ -462,8 +690,9 @@ func (c *Command) parseExpectedArgs(args []string) {
 				// No optional arguments before required arguments
 				for _, prev := range c.Args {
 					if !prev.Required {
-						fmt.Fprintf(os.Stderr, "\n  error: required argument `%s` not allowed after optional argument `%s`", arg, prev.Name)
-						os.Exit(1)
+						fmt.Fprintf(c.Program.stderr(), "\n  error: required argument `%s` not allowed after optional argument `%s`", arg, prev.Name)
+						c.Program.exit(1)
+						return
 					}
 				}
 				c.Args = append(c.Args, &Arg{Required: true, Name: arg[1 : len(arg)-1]})
@@ -480,6 +709,13 @@ func (c *Command) SetBody(body string) *Command {
 	return c
 }
 
+// SetMarkdown sets whether help renders the body as Markdown (see
+// Terminal.Markdown) instead of printing it as written.
+func (c *Command) SetMarkdown(markdown bool) *Command {
+	c.Markdown = markdown
+	return c
+}
+
 // SetAction sets the action associated with the command.
 func (c *Command) SetAction(action CommandAction) *Command {
 	c.Action = action
@@ -522,6 +758,8 @@ type Option struct {
 	Description string
 	Value       string
 	Default     string
+	Count       int  // Number of times the option was passed (e.g. -vvv)
+	Inline      bool // Value is only given as `--flag=value` (flags `--flag[=<value>]`)
 }
 
 // NewOption creates a new option.
@@ -529,13 +767,21 @@ func NewOption(program *Program, flags, description string, defaultValue ...stri
 	option := &Option{Program: program}
 	option.Flags = flags
 	option.Description = description
-	option.Required = strings.Contains(flags, "<")
-	option.Optional = strings.Contains(flags, "[")
+	option.Inline = strings.Contains(flags, "[=")
+	option.Required = strings.Contains(flags, "<") && !option.Inline
+	option.Optional = strings.Contains(flags, "[") && !option.Inline
 	option.Bool = strings.Contains(flags, "-no-")
-	options := regexp.MustCompile(`[ ,|]+`).Split(flags, -1)
-	option.Short = options[0]
-	if len(options) > 1 {
-		option.Long = options[1]
+	for _, flag := range regexp.MustCompile(`[ ,|]+`).Split(flags, -1) {
+		if option.Inline {
+			flag = strings.Split(flag, "[=")[0]
+		}
+		if strings.HasPrefix(flag, "--") && option.Long == "" {
+			option.Long = flag
+		} else if strings.HasPrefix(flag, "-") && !strings.HasPrefix(flag, "--") && option.Short == "" {
+			option.Short = flag
+		}
+	}
+	if option.Long != "" {
 		option.Name = strings.Replace(strings.Replace(option.Long, "--", "", -1), "no-", "", -1)
 	}
 	if len(defaultValue) == 1 {
@@ -552,7 +798,15 @@ type Topic struct {
 	Program     *Program
 	Description string
 	Topic       string
+	Title       string // Heading shown by help (Topic if empty)
 	Body        string
+	Markdown    bool // Body is rendered as Markdown by help
+}
+
+// SetTitle sets the heading shown when the topic is displayed.
+func (t *Topic) SetTitle(title string) *Topic {
+	t.Title = title
+	return t
 }
 
 // SetDescription sets the help topic description.
@@ -567,76 +821,127 @@ func (t *Topic) SetBody(body string) *Topic {
 	return t
 }
 
+// SetMarkdown sets whether help renders the topic body as Markdown (see
+// Terminal.Markdown) instead of printing it as written.
+func (t *Topic) SetMarkdown(markdown bool) *Topic {
+	t.Markdown = markdown
+	return t
+}
+
 // -----------------------------------------------------------------------
 
 // HelpAction is a default action used by cli to print out the standard
 // Help() message. The action can be replaced by a user-supplied implementation
 // to override the default behavior/format.
 func HelpAction(program *Program, command *Command, _ []string) {
+	program.describeOutputFormats()
 	// Print help - we look it here are any arguments (command or topics) and print those,
 	// otherwise, we print the main usage information
+	if tree := optionOf(command, "--tree"); tree != nil && tree.Value != "" {
+		program.DescribePlugins()
+		program.Terminal.Page(func(out io.Writer) {
+			fmt.Fprint(out, program.CommandTree().String())
+		})
+		return
+	}
+	if search := optionOf(command, "--search"); search != nil && search.Value != "" {
+		program.DescribePlugins()
+		program.Terminal.Page(func(out io.Writer) {
+			printSearch(program, out, search.Value)
+		})
+		return
+	}
 	if command != nil {
 		cmd := command.Args[0].Value
 
 		// Search commands for a match
 		helpCommand := program.Commands[cmd]
-		if helpCommand != nil && helpCommand.Command != "" {
-			fmt.Print("Usage: ", program.Exe)
-			if len(helpCommand.Options) > 0 {
-				fmt.Print(" [options]")
-			}
-			fmt.Println(" " + helpCommand.Flags)
-			fmt.Println()
-			if helpCommand.Body != "" {
-				fmt.Println(helpCommand.Body)
-			} else {
-				fmt.Println(helpCommand.Description)
+		if helpCommand != nil && helpCommand.IsPlugin() {
+			program.describePlugin(helpCommand)
+			if helpCommand.Description == "" {
+				// Plugins without metadata print their own help
+				program.executeSubCommand(helpCommand, []string{"--help"})
+				return
 			}
+		}
+		if helpCommand != nil && helpCommand.Command != "" {
+			program.Terminal.Page(func(out io.Writer) {
+				fmt.Fprint(out, "Usage: ", program.Exe)
+				if len(helpCommand.Options) > 0 {
+					fmt.Fprint(out, " [options]")
+				}
+				fmt.Fprintln(out, " "+helpCommand.Flags)
+				fmt.Fprintln(out)
+				if helpCommand.Body != "" && helpCommand.Markdown {
+					fmt.Fprint(out, program.Terminal.Markdown(helpCommand.Body))
+				} else if helpCommand.Body != "" {
+					fmt.Fprintln(out, helpCommand.Body)
+				} else {
+					fmt.Fprintln(out, helpCommand.Description)
+				}
+			})
 			return
 		}
 		// Search topics for a match
 		helpTopic := program.Topics[cmd]
 		if helpTopic != nil {
-			fmt.Println(helpTopic.Topic)
-			line := make([]string, len(helpTopic.Topic))
-			for i := range helpTopic.Topic {
-				line[i] = "="
-			}
-			fmt.Println(line)
-			fmt.Println()
-			if helpTopic.Body != "" {
-				fmt.Println(helpTopic.Body)
-			} else {
-				fmt.Println(helpTopic.Description)
-			}
+			program.Terminal.Page(func(out io.Writer) {
+				title := helpTopic.Topic
+				if helpTopic.Title != "" {
+					title = helpTopic.Title
+				}
+				fmt.Fprintln(out, title)
+				line := make([]string, len(title))
+				for i := range title {
+					line[i] = "="
+				}
+				fmt.Fprintln(out, line)
+				fmt.Fprintln(out)
+				if helpTopic.Body != "" && helpTopic.Markdown {
+					fmt.Fprint(out, program.Terminal.Markdown(helpTopic.Body))
+				} else if helpTopic.Body != "" {
+					fmt.Fprintln(out, helpTopic.Body)
+				} else {
+					fmt.Fprintln(out, helpTopic.Description)
+				}
+			})
 			return
 		}
 	}
 	HelpPrinter(program)
 }
 
-// HelpPrinter is the default help printing function
+// HelpPrinter is the default help printing function. Long help is displayed
+// through a pager (see Terminal.Page).
 func HelpPrinter(p *Program) {
+	p.DescribePlugins()
+	p.Terminal.Page(func(out io.Writer) {
+		printUsage(p, out)
+	})
+}
+
+// printUsage writes the program usage, options, commands and topics to `out`.
+func printUsage(p *Program, out io.Writer) {
 	defaultCommand, hasDefaultCommand := p.Commands["*"]
 
 	if p.Description != "" {
-		fmt.Println(p.Description)
-		fmt.Println()
+		fmt.Fprintln(out, p.Description)
+		fmt.Fprintln(out)
 	}
 
-	fmt.Print("Usage: ", p.Exe)
+	fmt.Fprint(out, "Usage: ", p.Exe)
 	if len(p.Options) > 0 {
-		fmt.Print(" [options]")
+		fmt.Fprint(out, " [options]")
 	}
 	if len(p.Commands) > 0 {
 		if hasDefaultCommand {
-			fmt.Print(" [command]")
+			fmt.Fprint(out, " [command]")
 		} else {
-			fmt.Print(" <command>")
+			fmt.Fprint(out, " <command>")
 		}
 	}
-	fmt.Println()
-	fmt.Println()
+	fmt.Fprintln(out)
+	fmt.Fprintln(out)
 
 	// We right pad by spaces all items from descriptions to create a nice lined up list of descriptions
 	// To do that, we iterate through all the items and find the longest and pad it by 3 spaces
@@ -655,21 +960,21 @@ func HelpPrinter(p *Program) {
 	}
 
 	if len(p.Options) > 0 {
-		fmt.Println("Global options are:")
-		fmt.Println()
+		fmt.Fprintln(out, "Global options are:")
+		fmt.Fprintln(out)
 		for _, option := range p.Options {
-			fmt.Print(padding)
-			fmt.Print(option.Flags)
+			fmt.Fprint(out, padding)
+			fmt.Fprint(out, option.Flags)
 			if len(option.Flags) < columnSize {
-				fmt.Print(spacing[0 : columnSize-len(option.Flags)])
+				fmt.Fprint(out, spacing[0:columnSize-len(option.Flags)])
 			}
 			if option.Default != "" {
-				fmt.Printf("%s (defaults to %v)\n", option.Description, option.Default)
+				fmt.Fprintf(out, "%s (defaults to %v)\n", option.Description, option.Default)
 			} else {
-				fmt.Println(option.Description)
+				fmt.Fprintln(out, option.Description)
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(out)
 	}
 
 	columnSize = spacer
@@ -685,46 +990,64 @@ func HelpPrinter(p *Program) {
 	}
 
 	if len(p.Commands) > 0 {
-		fmt.Println("The commands are:")
-		fmt.Println()
+		fmt.Fprintln(out, "The commands are:")
+		fmt.Fprintln(out)
 		for _, command := range p.Commands {
-			if command.Flags == "*" {
-				// Skip default command in command list - we display it at the bottom
+			if command.Flags == "*" || command.IsPlugin() {
+				// Skip default command and plugins in command list - we display them below
 				continue
 			}
-			fmt.Print(padding)
-			fmt.Print(command.Flags)
+			fmt.Fprint(out, padding)
+			fmt.Fprint(out, command.Flags)
 			if len(command.Flags) < columnSize {
-				fmt.Print(spacing[0 : columnSize-len(command.Flags)])
+				fmt.Fprint(out, spacing[0:columnSize-len(command.Flags)])
+			}
+			fmt.Fprintln(out, command.Description)
+		}
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Use \""+p.Exe+" help [command]\" for more information about a command.")
+		fmt.Fprintln(out)
+	}
+	if len(p.Execs) > 0 {
+		fmt.Fprintln(out, "Plugins:")
+		fmt.Fprintln(out)
+		for name, bin := range p.Execs {
+			command := p.Commands[name]
+			fmt.Fprint(out, padding)
+			fmt.Fprint(out, command.Flags)
+			if len(command.Flags) < columnSize {
+				fmt.Fprint(out, spacing[0:columnSize-len(command.Flags)])
+			}
+			if command.Description != "" {
+				fmt.Fprintln(out, command.Description)
+			} else {
+				fmt.Fprintln(out, path.Base(bin))
 			}
-			fmt.Println(command.Description)
 		}
-		fmt.Println()
-		fmt.Println("Use \"" + p.Exe + " help [command]\" for more information about a command.")
-		fmt.Println()
+		fmt.Fprintln(out)
 	}
 	if len(p.Topics) > 0 {
-		fmt.Println("Additional help topics:")
-		fmt.Println()
+		fmt.Fprintln(out, "Additional help topics:")
+		fmt.Fprintln(out)
 		for _, topic := range p.Topics {
-			fmt.Print(padding)
-			fmt.Print(topic.Topic)
+			fmt.Fprint(out, padding)
+			fmt.Fprint(out, topic.Topic)
 			if len(topic.Topic) < columnSize {
-				fmt.Print(spacing[0 : columnSize-len(topic.Topic)])
+				fmt.Fprint(out, spacing[0:columnSize-len(topic.Topic)])
 			}
-			fmt.Println(topic.Description)
+			fmt.Fprintln(out, topic.Description)
 		}
-		fmt.Println()
-		fmt.Println("Use \"" + p.Exe + " help [topic]\" for more information about that topic.")
-		fmt.Println()
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Use \""+p.Exe+" help [topic]\" for more information about that topic.")
+		fmt.Fprintln(out)
 	}
 
 	if hasDefaultCommand {
 		// We have a default command
-		fmt.Println("Default command:", defaultCommand.Description)
+		fmt.Fprintln(out, "Default command:", defaultCommand.Description)
 		if defaultCommand.Body != "" {
-			fmt.Println(defaultCommand.Body)
+			fmt.Fprintln(out, defaultCommand.Body)
 		}
-		fmt.Println()
+		fmt.Fprintln(out)
 	}
 }
@@ -2,6 +2,9 @@
 package cli_test
 
 import (
+	"bytes"
+	"io/ioutil"
+
 	. "github.com/gopackage/cli"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -259,4 +262,104 @@ var _ = Describe("Argument Parsing", func() {
 			})
 		})
 	})
+	Describe("Command option parsing", func() {
+		var program *Program
+		var received []string
+
+		BeforeEach(func() {
+			received = nil
+			program = New()
+			program.Exit = func(int) {}
+			program.Err = ioutil.Discard
+			program.Command("tcp <port>", "capture TCP packets on <port>").
+				Option("-H, --host <host>", "host address to bind to").
+				Option("-f, --force", "capture even if busy").
+				SetAction(func(program *Program, command *Command, unknownArgs []string) {
+					received = unknownArgs
+				})
+		})
+
+		It("should set command option values", func() {
+			command := program.ParseArgs([]string{"exe", "tcp", "80", "--host", "example.com", "-f"})
+			Ω(command.OptionFor("--host").Value).Should(Equal("example.com"))
+			Ω(command.OptionFor("-f").Value).Should(Equal("true"))
+		})
+		It("should still pass command options to the action", func() {
+			program.ParseArgs([]string{"exe", "tcp", "80", "-H", "example.com", "--other", "x", "-f"})
+			Ω(received).Should(Equal([]string{"-H", "example.com", "--other", "x", "-f"}))
+		})
+		It("should report missing command option arguments", func() {
+			code := 0
+			program.Exit = func(c int) { code = c }
+			program.ParseArgs([]string{"exe", "tcp", "80", "--host"})
+			Ω(code).Should(Equal(1))
+		})
+	})
+	Describe("Exit replacements that return", func() {
+		var program *Program
+		var codes []int
+		var ran bool
+
+		BeforeEach(func() {
+			codes, ran = nil, false
+			program = New()
+			program.Exit = func(c int) { codes = append(codes, c) }
+			program.Out = ioutil.Discard
+			program.Err = ioutil.Discard
+			program.Option("-n, --name <name>", "name to use")
+			program.Command("tcp <port>", "capture TCP packets on <port>").
+				Option("-H, --host <host>", "host address to bind to").
+				SetAction(func(program *Program, command *Command, unknownArgs []string) {
+					ran = true
+				})
+		})
+
+		It("should stop at a missing option argument", func() {
+			Ω(program.ParseArgs([]string{"exe", "tcp", "80", "--name"})).Should(BeNil())
+			Ω(program.ParseArgs([]string{"exe", "--name", "-H", "tcp", "80"})).Should(BeNil())
+			Ω(codes).Should(Equal([]int{1, 1}))
+			Ω(ran).Should(BeFalse())
+		})
+		It("should stop at a missing command argument or option argument", func() {
+			Ω(program.ParseArgs([]string{"exe", "tcp"})).Should(BeNil())
+			Ω(program.ParseArgs([]string{"exe", "tcp", "80", "--host"})).Should(BeNil())
+			Ω(codes).Should(Equal([]int{1, 1}))
+			Ω(ran).Should(BeFalse())
+		})
+		It("should stop after help", func() {
+			Ω(program.ParseArgs([]string{"exe", "--help", "-h", "--unknown"})).Should(BeNil())
+			Ω(codes).Should(Equal([]int{0}))
+		})
+		It("should run the command once arguments are valid", func() {
+			program.ParseArgs([]string{"exe", "tcp"})
+			Ω(program.ParseArgs([]string{"exe", "tcp", "80"})).ShouldNot(BeNil())
+			Ω(ran).Should(BeTrue())
+		})
+	})
+	Describe("Output streams", func() {
+		Context("with redirected program streams", func() {
+			var out, errOut bytes.Buffer
+			var program *Program
+
+			BeforeEach(func() {
+				out.Reset()
+				errOut.Reset()
+				program = New()
+				program.Out, program.Err = &out, &errOut
+				program.Exit = func(int) {}
+				program.Command("tcp <port>", "capture TCP packets on <port>")
+			})
+
+			It("should write help to the program output", func() {
+				program.ParseArgs([]string{"exe", "help"})
+				Ω(out.String()).Should(ContainSubstring("tcp <port>   capture TCP packets on <port>"))
+			})
+			It("should write terminal output to the program streams", func() {
+				program.Terminal.PushIndent().Info("indented")
+				program.Terminal.PopIndent().Fatalf("failed %d", 1)
+				Ω(out.String()).Should(Equal("  indented\n"))
+				Ω(errOut.String()).Should(Equal("failed 1\n"))
+			})
+		})
+	})
 })
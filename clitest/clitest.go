@@ -0,0 +1,197 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+
+// Package clitest runs cli programs inside a test binary, capturing their
+// output and exit code so command line behavior can be asserted directly or
+// compared against golden files.
+package clitest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gopackage/cli"
+)
+
+// UpdateFlag is the name of the test flag that rewrites golden files with the
+// actual output instead of comparing against them (`go test ./... -update`).
+const UpdateFlag = "update"
+
+func init() {
+	// Another package in the test binary may already own the flag.
+	if flag.Lookup(UpdateFlag) == nil {
+		flag.Bool(UpdateFlag, false, "update clitest golden files")
+	}
+}
+
+// Invocation describes a single run of a program.
+type Invocation struct {
+	Args  []string          // Full argv including the binary name in Args[0]
+	Env   map[string]string // Environment variables set for the run
+	Stdin string            // Text available on stdin
+	Dir   string            // Working directory for the run (current directory if empty)
+}
+
+// Result captures the observable behavior of a program run.
+type Result struct {
+	Command  *cli.Command // Command returned by ParseArgs (nil if the program exited)
+	Stdout   string       // Everything written to stdout
+	Stderr   string       // Everything written to stderr
+	ExitCode int          // Exit code (0 if the program never exited)
+	Exited   bool         // True if the program called Exit
+}
+
+// TestingT is the subset of testing.T (and GinkgoT()) used by clitest.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// exitSignal unwinds the program stack when the program calls Exit.
+type exitSignal struct {
+	code int
+}
+
+// runLock serializes runs as they share the process environment and working
+// directory.
+var runLock sync.Mutex
+
+// Run executes `program` with the provided invocation and returns the
+// captured result. The program's streams and Exit function are replaced for
+// the duration of the run so exiting never terminates the test binary.
+func Run(program *cli.Program, invocation Invocation) (result *Result, err error) {
+	runLock.Lock()
+	defer runLock.Unlock()
+
+	args := invocation.Args
+	if len(args) == 0 {
+		args = []string{"program"}
+	}
+
+	restoreEnv := setEnv(invocation.Env)
+	defer restoreEnv()
+
+	if invocation.Dir != "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chdir(invocation.Dir); err != nil {
+			return nil, err
+		}
+		defer os.Chdir(wd)
+	}
+
+	var stdout, stderr bytes.Buffer
+	out, errOut, in, exit := program.Out, program.Err, program.In, program.Exit
+	program.Out, program.Err, program.In = &stdout, &stderr, strings.NewReader(invocation.Stdin)
+	program.Exit = func(code int) {
+		panic(exitSignal{code: code})
+	}
+
+	result = &Result{}
+	defer func() {
+		program.Out, program.Err, program.In, program.Exit = out, errOut, in, exit
+		result.Stdout, result.Stderr = stdout.String(), stderr.String()
+	}()
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				signal, ok := r.(exitSignal)
+				if !ok {
+					panic(r)
+				}
+				result.Exited = true
+				result.ExitCode = signal.code
+			}
+		}()
+		result.Command = program.ParseArgs(args)
+	}()
+	return result, nil
+}
+
+// Output returns stdout and stderr along with the exit code in a single
+// stable rendering suitable for golden files.
+func (r *Result) Output() string {
+	var b bytes.Buffer
+	b.WriteString("-- stdout --\n")
+	b.WriteString(r.Stdout)
+	b.WriteString("-- stderr --\n")
+	b.WriteString(r.Stderr)
+	fmt.Fprintf(&b, "-- exit %d --\n", r.ExitCode)
+	return b.String()
+}
+
+// AssertGolden compares the combined Output of the result against the
+// golden file `testdata/<name>.golden`.
+func (r *Result) AssertGolden(t TestingT, name string) {
+	AssertGolden(t, name, r.Output())
+}
+
+// AssertGolden compares `actual` against the golden file
+// `testdata/<name>.golden`. When the test binary is run with -update the
+// golden file is (re)written instead.
+func AssertGolden(t TestingT, name, actual string) {
+	path := filepath.Join("testdata", name+".golden")
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Errorf("clitest: creating golden directory: %v", err)
+			return
+		}
+		if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Errorf("clitest: writing golden file: %v", err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Errorf("clitest: reading golden file (run with -%s to create it): %v", UpdateFlag, err)
+		return
+	}
+	if string(expected) != actual {
+		t.Errorf("clitest: output does not match %s\n--- expected\n%s\n--- actual\n%s", path, expected, actual)
+	}
+}
+
+// updating reports whether golden files should be rewritten.
+func updating() bool {
+	f := flag.Lookup(UpdateFlag)
+	if f == nil {
+		return false
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return f.Value.String() == "true"
+	}
+	update, _ := getter.Get().(bool)
+	return update
+}
+
+// setEnv applies `env` to the process environment and returns a function
+// restoring the previous values.
+func setEnv(env map[string]string) func() {
+	type previous struct {
+		value string
+		set   bool
+	}
+	saved := map[string]previous{}
+	for key, value := range env {
+		old, ok := os.LookupEnv(key)
+		saved[key] = previous{value: old, set: ok}
+		os.Setenv(key, value)
+	}
+	return func() {
+		for key, old := range saved {
+			if old.set {
+				os.Setenv(key, old.value)
+			} else {
+				os.Unsetenv(key)
+			}
+		}
+	}
+}
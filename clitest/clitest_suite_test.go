@@ -0,0 +1,14 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package clitest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestClitest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "clitest Test Suite")
+}
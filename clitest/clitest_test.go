@@ -0,0 +1,72 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package clitest_test
+
+import (
+	"bufio"
+	"os"
+
+	"github.com/gopackage/cli"
+	. "github.com/gopackage/cli/clitest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Run", func() {
+
+	var program *cli.Program
+
+	BeforeEach(func() {
+		program = cli.New()
+		program.SetDescription("Device troubleshooting tool")
+		program.Command("tcp <port>", "capture TCP packets on <port>").
+			SetAction(func(program *cli.Program, command *cli.Command, unknownArgs []string) {
+				program.Terminal.Infof("capturing on %s", command.Args[0].Value)
+			})
+		program.Command("greet", "greet the user named on stdin").
+			SetAction(func(program *cli.Program, command *cli.Command, unknownArgs []string) {
+				name, _ := bufio.NewReader(program.In).ReadString('\n')
+				program.Terminal.Infof("hello %s from %s", name[:len(name)-1], os.Getenv("GREETER"))
+			})
+		program.Command("fail", "always fails").
+			SetAction(func(program *cli.Program, command *cli.Command, unknownArgs []string) {
+				program.Terminal.Fatal("failed")
+			})
+	})
+
+	It("should capture stdout and the selected command", func() {
+		result, err := Run(program, Invocation{Args: []string{"tool", "tcp", "8080"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Command).ShouldNot(BeNil())
+		Ω(result.Command.Command).Should(Equal("tcp"))
+		Ω(result.Stdout).Should(Equal("capturing on 8080\n"))
+		Ω(result.Exited).Should(BeFalse())
+	})
+	It("should provide stdin and environment variables", func() {
+		result, err := Run(program, Invocation{
+			Args:  []string{"tool", "greet"},
+			Env:   map[string]string{"GREETER": "clitest"},
+			Stdin: "gopher\n",
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Stdout).Should(Equal("hello gopher from clitest\n"))
+		Ω(os.Getenv("GREETER")).Should(Equal(""))
+	})
+	It("should capture the exit code without exiting", func() {
+		result, err := Run(program, Invocation{Args: []string{"tool", "fail"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Exited).Should(BeTrue())
+		Ω(result.ExitCode).Should(Equal(1))
+		Ω(result.Stderr).Should(Equal("failed\n"))
+	})
+	It("should capture usage errors", func() {
+		result, err := Run(program, Invocation{Args: []string{"tool", "tcp"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.ExitCode).Should(Equal(1))
+		Ω(result.Stderr).Should(ContainSubstring("missing required argument `port`"))
+	})
+	It("should match golden output", func() {
+		result, err := Run(program, Invocation{Args: []string{"tool", "tcp", "8080"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		result.AssertGolden(GinkgoT(), "tcp")
+	})
+})
@@ -0,0 +1,112 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"io"
+	"os"
+)
+
+// Color modes accepted by Terminal.ColorMode and the --color option.
+const (
+	ColorAuto   = "auto"   // Color when writing to a terminal (default)
+	ColorAlways = "always" // Always emit styles and cursor control
+	ColorNever  = "never"  // Never emit styles (cursor control still works on terminals)
+)
+
+// Sets the color mode (ColorAuto, ColorAlways or ColorNever), overriding the
+// --color option.
+func (t *Terminal) SetColorMode(mode string) *Terminal {
+	t.ColorMode = mode
+	return t
+}
+
+// Returns true if styles are emitted on the program output.
+func (t *Terminal) ColorEnabled() bool {
+	return t.colorFor(t.Program.stdout())
+}
+
+// Returns true if styles are emitted on the program error output.
+func (t *Terminal) ErrColorEnabled() bool {
+	return t.colorFor(t.Program.stderr())
+}
+
+// Returns `text` wrapped in the combination of `styles` when color is enabled
+// on the program output, or unchanged otherwise.
+func (t *Terminal) Paint(text string, styles ...Style) string {
+	if !t.ColorEnabled() {
+		return text
+	}
+	return Styled(text, styles...)
+}
+
+// Returns true if cursor control (movement, clearing, hiding the cursor) is
+// emitted on the program output: when it is a terminal other than TERM=dumb,
+// or when escapes are forced with ColorAlways or CLICOLOR_FORCE. NO_COLOR and
+// ColorNever only turn off styles, so prompts and spinners keep redrawing in
+// place.
+func (t *Terminal) CursorEnabled() bool {
+	if t.colorMode() == ColorAlways {
+		return true
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	return os.Getenv("TERM") != "dumb" && writerIsTerminal(t.Program.stdout())
+}
+
+// Returns the color mode from the terminal setting or --color option.
+func (t *Terminal) colorMode() string {
+	if t.ColorMode != "" {
+		return t.ColorMode
+	}
+	if option := t.Program.colorOption(); option != nil {
+		switch option.Value {
+		case "":
+		case "true":
+			// --color without a value
+			return ColorAlways
+		default:
+			return option.Value
+		}
+	}
+	return ColorAuto
+}
+
+// Decides if escape sequences should be written to `w`. An explicit mode
+// wins, then NO_COLOR (disable), CLICOLOR_FORCE (enable) and TERM=dumb
+// (disable) are honored before checking whether `w` is a terminal.
+func (t *Terminal) colorFor(w io.Writer) bool {
+	switch t.colorMode() {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return writerIsTerminal(w)
+}
+
+// Returns the built in --color option (nil if not registered).
+func (p *Program) colorOption() *Option {
+	if p == nil {
+		return nil
+	}
+	return p.color
+}
+
+// Returns true if `w` is a file connected to a terminal.
+func writerIsTerminal(w io.Writer) bool {
+	if tw, ok := w.(interface{ isTerminal() bool }); ok {
+		return tw.isTerminal()
+	}
+	file, ok := w.(*os.File)
+	return ok && isTerminal(int(file.Fd()))
+}
@@ -0,0 +1,74 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cursor", func() {
+
+	var terminal *Terminal
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		out.Reset()
+		program := New()
+		program.Out = &out
+		terminal = program.Terminal.SetColorMode(ColorAlways)
+	})
+
+	It("should move to a column and row", func() {
+		terminal.Move(10, 2)
+		Ω(out.String()).Should(Equal("\033[2;10H"))
+	})
+	It("should hide and show the cursor", func() {
+		terminal.Hide().Show()
+		Ω(out.String()).Should(Equal("\033[?25l\033[?25h"))
+	})
+	It("should save and restore the cursor and set scroll regions", func() {
+		terminal.SaveCursor().SetScrollRegion(2, 20).ScrollUp(1).ResetScrollRegion().RestoreCursor()
+		Ω(out.String()).Should(Equal("\0337\033[2;20r\033[1S\033[r\0338"))
+	})
+	It("should clear parts of the line and screen", func() {
+		terminal.ClearLineEnd().ClearLineStart().ClearScreenEnd().ClearScreenStart().Clear()
+		Ω(out.String()).Should(Equal("\033[0K\033[1K\033[0J\033[1J\033[2J\033[H"))
+	})
+	It("should write a frame at once", func() {
+		Ω(terminal.Frame(func() {
+			terminal.Move(1, 1).Print("title")
+			Ω(out.String()).Should(BeEmpty())
+		})).Should(Succeed())
+		Ω(out.String()).Should(Equal("\033[1;1Htitle"))
+	})
+	It("should write frames while a spinner runs", func() {
+		spinner := terminal.Spinner("waiting")
+		for i := 0; i < 20; i++ {
+			Ω(terminal.Frame(func() {
+				terminal.Move(1, 1).Print("title")
+				time.Sleep(10 * time.Millisecond)
+			})).Should(Succeed())
+		}
+		spinner.Success("done")
+		Ω(out.String()).Should(ContainSubstring("\033[1;1Htitle"))
+	})
+	It("should not emit sequences when output isn't a terminal", func() {
+		terminal.SetColorMode(ColorAuto).Move(1, 1).Hide().SaveCursor().Style(Bold)
+		Ω(out.String()).Should(BeEmpty())
+	})
+	It("should only turn off styles when color is disabled", func() {
+		os.Setenv("CLICOLOR_FORCE", "1")
+		defer os.Unsetenv("CLICOLOR_FORCE")
+		terminal.SetColorMode(ColorNever).Up(3).ClearLine().Style(Bold).Hide().Reset()
+		Ω(out.String()).Should(Equal("\033[3A\033[2K\033[?25l"))
+	})
+	It("should require a terminal to query the cursor position", func() {
+		_, _, err := terminal.CursorPosition()
+		Ω(err).Should(Equal(ErrNotInteractive))
+	})
+})
@@ -0,0 +1,401 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DiffOptions controls how Terminal.Diff displays differences.
+type DiffOptions struct {
+	Context    int    // Unchanged lines around changes (3 if 0, negative for none)
+	SideBySide bool   // Show the texts in two columns instead of a unified diff
+	Width      int    // Side-by-side width (0 fits the terminal, or 80 columns)
+	Words      bool   // Highlight the changed words within changed lines (both modes)
+	From, To   string // Names in the unified header ("a" and "b" if empty)
+}
+
+// diffOp is a line of a diff: kept (' '), deleted ('-') or inserted ('+').
+type diffOp struct {
+	kind byte
+	text string
+	a, b int // 0-based line numbers in a and b
+}
+
+// diffHunk is a range of operations shown together.
+type diffHunk struct {
+	ops []diffOp
+}
+
+// Diff compares `a` and `b` line by line and returns the differences as a
+// unified diff (or side by side columns), colored when color is enabled.
+// Returns an empty string when the texts are equal.
+func (t *Terminal) Diff(a, b string, opts DiffOptions) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+	context := opts.Context
+	if context == 0 {
+		context = 3
+	} else if context < 0 {
+		context = 0
+	}
+	hunks := diffHunks(ops, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+	if opts.SideBySide {
+		return t.sideBySide(hunks, opts)
+	}
+	return t.unified(hunks, opts)
+}
+
+// unified renders hunks in the unified diff format.
+func (t *Terminal) unified(hunks []diffHunk, opts DiffOptions) string {
+	from, to := opts.From, opts.To
+	if from == "" {
+		from = "a"
+	}
+	if to == "" {
+		to = "b"
+	}
+	var b strings.Builder
+	b.WriteString(t.Paint("--- "+from, Bold) + "\n")
+	b.WriteString(t.Paint("+++ "+to, Bold) + "\n")
+	for _, hunk := range hunks {
+		b.WriteString(t.Paint(hunk.header(), Fg(Cyan)) + "\n")
+		ops := hunk.ops
+		for i := 0; i < len(ops); {
+			if ops[i].kind == ' ' {
+				b.WriteString(" " + ops[i].text + "\n")
+				i++
+				continue
+			}
+			// A block of deletions followed by insertions
+			deleted, inserted := changeBlock(ops[i:])
+			i += len(deleted) + len(inserted)
+			for j, op := range deleted {
+				text := op.text
+				if opts.Words && j < len(inserted) && t.ColorEnabled() {
+					text, _ = wordDiff(op.text, inserted[j].text, Fg(Red), NewStyle(Fg(Red), Inverse), Fg(Green), NewStyle(Fg(Green), Inverse))
+					b.WriteString(t.Paint("-", Fg(Red)) + text + "\n")
+					continue
+				}
+				b.WriteString(t.Paint("-"+text, Fg(Red)) + "\n")
+			}
+			for j, op := range inserted {
+				text := op.text
+				if opts.Words && j < len(deleted) && t.ColorEnabled() {
+					_, text = wordDiff(deleted[j].text, op.text, Fg(Red), NewStyle(Fg(Red), Inverse), Fg(Green), NewStyle(Fg(Green), Inverse))
+					b.WriteString(t.Paint("+", Fg(Green)) + text + "\n")
+					continue
+				}
+				b.WriteString(t.Paint("+"+text, Fg(Green)) + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// sideBySide renders hunks in two columns: `a` on the left and `b` on the
+// right with a marker between them (`|` changed, `<` deleted, `>` inserted).
+func (t *Terminal) sideBySide(hunks []diffHunk, opts DiffOptions) string {
+	width := opts.Width
+	if width == 0 {
+		width, _ = t.Size()
+	}
+	if width == 0 {
+		width = 80
+	}
+	column := (width - 3) / 2
+	if column < 1 {
+		column = 1
+	}
+	var b strings.Builder
+	row := func(left, marker, right string, leftStyle, rightStyle Style) {
+		left = truncate(left, column, "…")
+		right = strings.TrimRight(truncate(right, column, "…"), " ")
+		padding := strings.Repeat(" ", column-utf8.RuneCountInString(left))
+		leftText, rightText := t.Paint(left, leftStyle), t.Paint(right, rightStyle)
+		if opts.Words && marker == "|" && t.ColorEnabled() {
+			leftText, rightText = wordDiff(left, right, leftStyle, leftStyle.With(Inverse), rightStyle, rightStyle.With(Inverse))
+		}
+		b.WriteString(leftText + padding + " " + marker + " " + rightText)
+		b.WriteString("\n")
+	}
+	for _, hunk := range hunks {
+		b.WriteString(t.Paint(hunk.header(), Fg(Cyan)) + "\n")
+		ops := hunk.ops
+		for i := 0; i < len(ops); {
+			if ops[i].kind == ' ' {
+				row(ops[i].text, " ", ops[i].text, Style{}, Style{})
+				i++
+				continue
+			}
+			deleted, inserted := changeBlock(ops[i:])
+			i += len(deleted) + len(inserted)
+			for j := 0; j < len(deleted) || j < len(inserted); j++ {
+				switch {
+				case j < len(deleted) && j < len(inserted):
+					row(deleted[j].text, "|", inserted[j].text, Fg(Red), Fg(Green))
+				case j < len(deleted):
+					row(deleted[j].text, "<", "", Fg(Red), Style{})
+				default:
+					row("", ">", inserted[j].text, Style{}, Fg(Green))
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+// header returns the `@@ -a,n +b,m @@` line of the hunk.
+func (h diffHunk) header() string {
+	aStart, bStart, aCount, bCount := -1, -1, 0, 0
+	for _, op := range h.ops {
+		if op.kind != '+' {
+			if aStart < 0 {
+				aStart = op.a
+			}
+			aCount++
+		}
+		if op.kind != '-' {
+			if bStart < 0 {
+				bStart = op.b
+			}
+			bCount++
+		}
+	}
+	// Empty ranges name the line before them
+	if aStart < 0 {
+		aStart = h.ops[0].a - 1
+	}
+	if bStart < 0 {
+		bStart = h.ops[0].b - 1
+	}
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(aStart+1, aCount), hunkRange(bStart+1, bCount))
+}
+
+// hunkRange formats the start and length of a hunk range.
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// changeBlock splits the deletions and insertions at the start of `ops`.
+func changeBlock(ops []diffOp) (deleted, inserted []diffOp) {
+	i := 0
+	for i < len(ops) && ops[i].kind == '-' {
+		deleted = append(deleted, ops[i])
+		i++
+	}
+	for i < len(ops) && ops[i].kind == '+' {
+		inserted = append(inserted, ops[i])
+		i++
+	}
+	return deleted, inserted
+}
+
+// diffHunks groups changes with `context` unchanged lines around them,
+// merging changes whose context overlaps.
+func diffHunks(ops []diffOp, context int) []diffHunk {
+	var hunks []diffHunk
+	start, end := -1, -1 // Range of ops in the current hunk
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		from, to := i-context, i+context+1
+		if from < 0 {
+			from = 0
+		}
+		if to > len(ops) {
+			to = len(ops)
+		}
+		if start >= 0 && from <= end {
+			end = to
+			continue
+		}
+		if start >= 0 {
+			hunks = append(hunks, diffHunk{ops: ops[start:end]})
+		}
+		start, end = from, to
+	}
+	if start >= 0 {
+		hunks = append(hunks, diffHunk{ops: ops[start:end]})
+	}
+	return hunks
+}
+
+// diffLines returns the operations turning `a` into `b` using the linear
+// space variant of the Myers shortest edit script algorithm, with deletions
+// before insertions in each block of changes.
+func diffLines(a, b []string) []diffOp {
+	d := &differ{a: a, b: b}
+	d.diff(0, len(a), 0, len(b))
+	// Move deletions before insertions in each block of changes
+	for i := 0; i < len(d.ops); {
+		if d.ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(d.ops) && d.ops[j].kind != ' ' {
+			j++
+		}
+		block := d.ops[i:j]
+		sort.SliceStable(block, func(x, y int) bool {
+			return block[x].kind == '-' && block[y].kind == '+'
+		})
+		i = j
+	}
+	return d.ops
+}
+
+// differ collects the operations of a diff.
+type differ struct {
+	a, b []string
+	ops  []diffOp
+}
+
+// diff adds the operations turning a[aLo:aHi] into b[bLo:bHi], splitting the
+// problem at the middle of an optimal path until it is trivial.
+func (d *differ) diff(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.ops = append(d.ops, diffOp{kind: ' ', text: d.a[aLo], a: aLo, b: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aHi > aLo && bHi > bLo && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+		suffix++
+	}
+	x, y := -1, -1
+	if aLo < aHi && bLo < bHi {
+		x, y = d.middle(aLo, aHi, bLo, bHi)
+	}
+	if (x == aLo && y == bLo) || (x == aHi && y == bHi) {
+		// No smaller parts (shouldn't happen): replace the lines
+		x = -1
+	}
+	if x >= 0 {
+		d.diff(aLo, x, bLo, y)
+		d.diff(x, aHi, y, bHi)
+	} else {
+		for i := aLo; i < aHi; i++ {
+			d.ops = append(d.ops, diffOp{kind: '-', text: d.a[i], a: i, b: bLo})
+		}
+		for j := bLo; j < bHi; j++ {
+			d.ops = append(d.ops, diffOp{kind: '+', text: d.b[j], a: aHi, b: j})
+		}
+	}
+	for i := 0; i < suffix; i++ {
+		d.ops = append(d.ops, diffOp{kind: ' ', text: d.a[aHi+i], a: aHi + i, b: bHi + i})
+	}
+}
+
+// middle returns a point of an optimal path from (aLo, bLo) to (aHi, bHi),
+// searching forwards from the start and backwards from the end at the same
+// time until the paths overlap.
+func (d *differ) middle(aLo, aHi, bLo, bHi int) (int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	forward := make([]int, 2*max+3)  // Furthest x on each diagonal from the start
+	backward := make([]int, 2*max+3) // Furthest x on each diagonal from the end
+	for D := 0; D <= max; D++ {
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if c := delta - k; odd && c >= -(D-1) && c <= D-1 && x+backward[offset+c] >= n {
+				return aLo + x, bLo + y
+			}
+		}
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if c := delta - k; !odd && c >= -D && c <= D && x+forward[offset+c] >= n {
+				return aHi - x, bHi - y
+			}
+		}
+	}
+	return -1, -1
+}
+
+// wordDiff compares two lines word by word, returning them with unchanged
+// words in the base styles and changed words in the highlight styles.
+func wordDiff(a, b string, aStyle, aChanged, bStyle, bChanged Style) (string, string) {
+	ops := diffLines(splitWords(a), splitWords(b))
+	var left, right strings.Builder
+	for _, op := range ops {
+		switch op.kind {
+		case ' ':
+			left.WriteString(aStyle.Wrap(op.text))
+			right.WriteString(bStyle.Wrap(op.text))
+		case '-':
+			left.WriteString(aChanged.Wrap(op.text))
+		case '+':
+			right.WriteString(bChanged.Wrap(op.text))
+		}
+	}
+	return left.String(), right.String()
+}
+
+// splitLines splits text into lines, ignoring the final line ending.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// splitWords splits a line into words, runs of spaces and punctuation so
+// joining the parts gives back the line.
+func splitWords(line string) []string {
+	var words []string
+	start := 0
+	class := func(r rune) int {
+		switch {
+		case unicode.IsSpace(r):
+			return 0
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		}
+		return 2
+	}
+	runes := []rune(line)
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) || class(runes[i]) != class(runes[i-1]) || class(runes[i]) == 2 {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return words
+}
@@ -0,0 +1,76 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diff", func() {
+
+	var terminal *Terminal
+	before := "host: example.com\nport: 80\nuser: admin\ntimeout: 30\nretries: 3\nlog: info\ncolor: auto\ncache: on\n"
+	after := "host: example.com\nport: 8080\nuser: admin\ntimeout: 30\nretries: 3\nlog: info\ncolor: auto\ncache: on\nproxy: none\n"
+
+	BeforeEach(func() {
+		terminal = New().Terminal
+	})
+
+	It("should be empty for equal texts", func() {
+		Ω(terminal.Diff(before, before, DiffOptions{})).Should(BeEmpty())
+	})
+	It("should produce unified diffs with context", func() {
+		Ω(terminal.Diff(before, after, DiffOptions{Context: 1, From: "old.yaml", To: "new.yaml"})).Should(Equal("" +
+			"--- old.yaml\n" +
+			"+++ new.yaml\n" +
+			"@@ -1,3 +1,3 @@\n" +
+			" host: example.com\n" +
+			"-port: 80\n" +
+			"+port: 8080\n" +
+			" user: admin\n" +
+			"@@ -8 +8,2 @@\n" +
+			" cache: on\n" +
+			"+proxy: none\n"))
+	})
+	It("should merge changes with overlapping context", func() {
+		Ω(terminal.Diff(before, after, DiffOptions{})).Should(ContainSubstring("@@ -1,8 +1,9 @@\n"))
+	})
+	It("should handle empty texts", func() {
+		Ω(terminal.Diff("", "a\nb\n", DiffOptions{})).Should(HaveSuffix("@@ -0,0 +1,2 @@\n+a\n+b\n"))
+		Ω(terminal.Diff("a\n", "", DiffOptions{})).Should(HaveSuffix("@@ -1 +0,0 @@\n-a\n"))
+	})
+	It("should color lines and highlight changed words", func() {
+		terminal.SetColorMode(ColorAlways)
+		diff := terminal.Diff("port: 80\n", "port: 8080\n", DiffOptions{Words: true})
+		Ω(diff).Should(ContainSubstring("\033[31m-\033[0m\033[31mport\033[0m\033[31m:\033[0m\033[31m \033[0m\033[31;7m80\033[0m\n"))
+		Ω(diff).Should(ContainSubstring("\033[32;7m8080\033[0m\n"))
+	})
+	It("should show texts side by side", func() {
+		Ω(terminal.Diff("a\nb\nc\n", "a\nB\nc\nd\n", DiffOptions{SideBySide: true, Width: 23})).Should(Equal("" +
+			"@@ -1,3 +1,4 @@\n" +
+			"a            a\n" +
+			"b          | B\n" +
+			"c            c\n" +
+			"           > d\n"))
+	})
+	It("should highlight changed words side by side", func() {
+		terminal.SetColorMode(ColorAlways)
+		diff := terminal.Diff("port: 80\n", "port: 8080\n", DiffOptions{SideBySide: true, Words: true, Width: 31})
+		Ω(diff).Should(ContainSubstring("\033[31;7m80\033[0m       | "))
+		Ω(diff).Should(HaveSuffix("\033[32;7m8080\033[0m\n"))
+	})
+	It("should diff long texts", func() {
+		var a, b strings.Builder
+		for i := 0; i < 4000; i++ {
+			fmt.Fprintf(&a, "a%d\n", i)
+			fmt.Fprintf(&b, "b%d\n", i)
+		}
+		diff := terminal.Diff(a.String(), b.String(), DiffOptions{})
+		Ω(strings.Count(diff, "\n-a")).Should(Equal(4000))
+		Ω(strings.Count(diff, "\n+b")).Should(Equal(4000))
+	})
+})
@@ -0,0 +1,218 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// UserError is an error meant to be read by the person running the program.
+// Besides the message and cause it carries an optional hint on how to fix
+// the problem, a documentation URL and the exit code to use. Create user
+// errors with NewUserError.
+type UserError struct {
+	Message string
+	Hint    string // How to fix the problem (optional)
+	URL     string // Documentation about the problem (optional)
+	Code    int    // Program exit code (1 if 0)
+	Err     error  // Underlying cause (optional)
+
+	stack []uintptr
+}
+
+// NewUserError creates a user error with a message and an optional cause
+// (which may be nil). The call stack is recorded and shown at debug
+// verbosity.
+func NewUserError(message string, cause error) *UserError {
+	stack := make([]uintptr, 32)
+	stack = stack[:runtime.Callers(2, stack)]
+	return &UserError{Message: message, Err: cause, stack: stack}
+}
+
+// Sets the hint displayed below the error.
+func (e *UserError) SetHint(hint string) *UserError {
+	e.Hint = hint
+	return e
+}
+
+// Sets the documentation URL displayed below the error.
+func (e *UserError) SetURL(url string) *UserError {
+	e.URL = url
+	return e
+}
+
+// Sets the program exit code.
+func (e *UserError) SetCode(code int) *UserError {
+	e.Code = code
+	return e
+}
+
+// Error returns the message followed by the cause.
+func (e *UserError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the cause.
+func (e *UserError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the program exit code for the error.
+func (e *UserError) ExitCode() int {
+	if e.Code == 0 {
+		return 1
+	}
+	return e.Code
+}
+
+// ExitCode returns the exit code for `err`: the code of the first error in
+// its chain with an `ExitCode() int` method (such as UserError or
+// exec.ExitError), or 1.
+func ExitCode(err error) int {
+	code := 1
+	walkErrors(err, func(err error, depth int) bool {
+		if coder, ok := err.(interface{ ExitCode() int }); ok && coder.ExitCode() > 0 {
+			code = coder.ExitCode()
+			return false
+		}
+		return true
+	})
+	return code
+}
+
+// walkErrors calls `visit` for `err` and every error it wraps, following
+// both errors.Unwrap and joined errors (`Unwrap() []error`). `depth` is 0 for
+// the chain of the top error and increases inside joined errors. Walking
+// stops when `visit` returns false.
+func walkErrors(err error, visit func(err error, depth int) bool) bool {
+	var walk func(err error, depth int) bool
+	walk = func(err error, depth int) bool {
+		for ; !isNil(err); err = errors.Unwrap(err) {
+			if !visit(err, depth) {
+				return false
+			}
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				for _, e := range joined.Unwrap() {
+					if !walk(e, depth+1) {
+						return false
+					}
+				}
+				return true
+			}
+		}
+		return true
+	}
+	return walk(err, 0)
+}
+
+// isNil returns true for nil errors, including typed nil pointers.
+func isNil(err error) bool {
+	if err == nil {
+		return true
+	}
+	v := reflect.ValueOf(err)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// formatError renders `err` for people: its message, a "caused by" line per
+// wrapped error, hints and documentation URLs from user errors and, at debug
+// verbosity, stack details.
+func (t *Terminal) formatError(err error) string {
+	color := t.ErrColorEnabled()
+	paint := func(text string, style Style) string {
+		if color {
+			return style.Wrap(text)
+		}
+		return text
+	}
+
+	var b strings.Builder
+	var hints, urls []string
+	var details string
+	line := func(indent int, text string) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(strings.Repeat("  ", indent) + text)
+	}
+	var describe func(err error, indent int, prefix string)
+	describe = func(err error, indent int, prefix string) {
+		first := true
+		for e := err; !isNil(e); e = errors.Unwrap(e) {
+			message := e.Error()
+			// Wrapping errors usually repeat the cause at the end of the message
+			if next := errors.Unwrap(e); !isNil(next) {
+				message = strings.TrimSuffix(message, ": "+next.Error())
+			}
+			if user, ok := e.(*UserError); ok {
+				message = user.Message
+				if user.Hint != "" {
+					hints = append(hints, user.Hint)
+				}
+				if user.URL != "" {
+					urls = append(urls, user.URL)
+				}
+				if details == "" && len(user.stack) > 0 {
+					details = formatStack(user.stack)
+				}
+			}
+			if joined, ok := e.(interface{ Unwrap() []error }); ok {
+				errs := joined.Unwrap()
+				if first {
+					line(indent, prefix+fmt.Sprintf("%d errors occurred:", len(errs)))
+				}
+				for _, child := range errs {
+					describe(child, indent+1, "- ")
+				}
+				return
+			}
+			if first {
+				line(indent, prefix+message)
+				first = false
+			} else {
+				line(indent+1, paint("caused by: ", Dim)+message)
+			}
+		}
+	}
+	describe(err, 0, "")
+
+	for _, hint := range hints {
+		line(1, paint("hint: ", Fg(Cyan))+hint)
+	}
+	for _, url := range urls {
+		line(1, paint("see: ", Fg(Cyan))+url)
+	}
+	if t.Enabled(LevelDebug) {
+		if verbose := fmt.Sprintf("%+v", err); verbose != err.Error() {
+			// Errors that format extra details (such as stack traces) with %+v
+			details = verbose
+		}
+		if details != "" {
+			line(0, paint(strings.TrimRight(details, "\n"), Dim))
+		}
+	}
+	return b.String()
+}
+
+// formatStack formats recorded program counters as function and file lines.
+func formatStack(stack []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "  %s\n      %s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			return b.String()
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// valueError is an error implemented by a non-pointer type.
+type valueError struct{ reason string }
+
+func (e valueError) Error() string { return e.reason }
+
+// joinedError combines several errors like errors.Join.
+type joinedError []error
+
+func (e joinedError) Error() string {
+	var messages []string
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e joinedError) Unwrap() []error { return e }
+
+var _ = Describe("Error rendering", func() {
+
+	var program *Program
+	var stderr bytes.Buffer
+	var code int
+
+	BeforeEach(func() {
+		stderr.Reset()
+		code = -1
+		program = New()
+		program.Out = &bytes.Buffer{}
+		program.Err = &stderr
+		program.Exit = func(c int) { code = c }
+	})
+
+	It("should print the cause chain instead of Go internals", func() {
+		cause := valueError{"connection refused"}
+		err := fmt.Errorf("load config: %w", fmt.Errorf("fetch https://example.com: %w", cause))
+		program.Terminal.Error(err, "")
+		Ω(stderr.String()).Should(Equal("" +
+			"error: load config\n" +
+			"  caused by: fetch https://example.com\n" +
+			"  caused by: connection refused\n"))
+		Ω(code).Should(Equal(1))
+	})
+	It("should print hints, documentation and use the exit code of user errors", func() {
+		err := NewUserError("no project found", errors.New("stat project.yaml: no such file")).
+			SetHint("run `tool init` to create one").
+			SetURL("https://example.com/docs/projects").
+			SetCode(3)
+		program.Terminal.Error(fmt.Errorf("build: %w", err), "cannot build")
+		Ω(stderr.String()).Should(Equal("" +
+			"error: cannot build\n" +
+			"  build\n" +
+			"    caused by: no project found\n" +
+			"    caused by: stat project.yaml: no such file\n" +
+			"    hint: run `tool init` to create one\n" +
+			"    see: https://example.com/docs/projects\n"))
+		Ω(code).Should(Equal(3))
+		Ω(ExitCode(err)).Should(Equal(3))
+	})
+	It("should list joined errors", func() {
+		err := fmt.Errorf("deploy: %w", joinedError{errors.New("web: timeout"), NewUserError("db: locked", nil)})
+		program.Terminal.Error(err, "")
+		Ω(stderr.String()).Should(Equal("" +
+			"error: deploy\n" +
+			"  - web: timeout\n" +
+			"  - db: locked\n"))
+	})
+	It("should show stack details only at debug verbosity", func() {
+		err := NewUserError("bad input", nil)
+		program.Terminal.Error(err, "")
+		Ω(stderr.String()).ShouldNot(ContainSubstring("errors_test.go"))
+
+		stderr.Reset()
+		program.Terminal.SetLevel(LevelDebug)
+		program.Terminal.Error(err, "")
+		Ω(stderr.String()).Should(HavePrefix("error: bad input\n"))
+		Ω(stderr.String()).Should(ContainSubstring("errors_test.go"))
+	})
+	It("should ignore nil errors", func() {
+		var err *UserError
+		program.Terminal.Error(err, "")
+		program.Terminal.Error(nil, "")
+		Ω(stderr.String()).Should(BeEmpty())
+		Ω(code).Should(Equal(-1))
+	})
+})
@@ -0,0 +1,187 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+
+//go:build go1.16
+// +build go1.16
+
+package cli
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HelpLanguages lists the language codes recognized in help file names, so
+// that `filters.fr.md` is a translation while `config.env.md` is a topic of
+// its own. Add codes to load translations into other languages.
+var HelpLanguages = map[string]bool{
+	"ar": true, "bg": true, "bn": true, "ca": true, "cs": true, "da": true,
+	"de": true, "el": true, "en": true, "es": true, "et": true, "eu": true,
+	"fa": true, "fi": true, "fr": true, "ga": true, "gl": true, "he": true,
+	"hi": true, "hr": true, "hu": true, "id": true, "is": true, "it": true,
+	"ja": true, "ko": true, "lt": true, "lv": true, "ms": true, "nb": true,
+	"nl": true, "nn": true, "no": true, "pl": true, "pt": true, "ro": true,
+	"ru": true, "sk": true, "sl": true, "sr": true, "sv": true, "sw": true,
+	"ta": true, "th": true, "tr": true, "uk": true, "ur": true, "vi": true,
+	"zh": true,
+}
+
+// localeName matches the locale part of help file names (`fr`, `pt_BR`).
+var localeName = regexp.MustCompile(`^([a-z]{2,3})([_-][A-Za-z]{2})?$`)
+
+// helpFile is a help document variant loaded from a file system.
+type helpFile struct {
+	locale string // Locale ("" for the default)
+	path   string
+}
+
+// LoadHelp registers help from the Markdown (`.md`) files under `dir` in
+// `fsys`, such as a `//go:embed help` tree. Each file becomes a help topic
+// named after the file, unless its front matter names a command whose body
+// it provides instead. Front matter is an optional block of `key: value`
+// lines between `---` lines at the top of the file:
+//
+//	---
+//	title: Capture filters
+//	description: writing capture filters
+//	---
+//
+// The keys are `title`, `description`, `name` (the topic name) and `command`.
+// Bodies are rendered as Markdown by help. Translations are files named
+// `<name>.<locale>.md` with a language from HelpLanguages (for example
+// `filters.fr.md` or `filters.pt_BR.md`); the variant matching the user's
+// locale ($LC_ALL, $LC_MESSAGES or $LANG) is used when there is one.
+func (p *Program) LoadHelp(fsys fs.FS, dir string) error {
+	variants := map[string][]helpFile{}
+	err := fs.WalkDir(fsys, dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || path.Ext(file) != ".md" {
+			return nil
+		}
+		name := strings.TrimSuffix(path.Base(file), ".md")
+		locale := ""
+		if i := strings.LastIndex(name, "."); i > 0 && isLocale(name[i+1:]) {
+			name, locale = name[:i], name[i+1:]
+		}
+		variants[name] = append(variants[name], helpFile{locale: locale, path: file})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(variants))
+	for name := range variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	locales := userLocales()
+	for _, name := range names {
+		file := chooseLocale(variants[name], locales)
+		data, err := fs.ReadFile(fsys, file.path)
+		if err != nil {
+			return err
+		}
+		meta, body := frontMatter(string(data))
+		if meta["name"] != "" {
+			name = meta["name"]
+		}
+		if command := meta["command"]; command != "" {
+			c := p.Commands[command]
+			if c == nil {
+				return &fs.PathError{Op: "load help", Path: file.path, Err: fs.ErrNotExist}
+			}
+			c.SetBody(body).SetMarkdown(true)
+			if meta["description"] != "" {
+				c.Description = meta["description"]
+			}
+			continue
+		}
+		p.Topic(name, meta["description"]).SetTitle(meta["title"]).SetBody(body).SetMarkdown(true)
+	}
+	return nil
+}
+
+// isLocale reports whether the last part of a help file name is a locale.
+func isLocale(text string) bool {
+	match := localeName.FindStringSubmatch(text)
+	return match != nil && HelpLanguages[match[1]]
+}
+
+// frontMatter splits the `key: value` front matter from a document.
+func frontMatter(text string) (map[string]string, string) {
+	meta := map[string]string{}
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	if !strings.HasPrefix(text, "---\n") {
+		return meta, strings.TrimSpace(text)
+	}
+	end := strings.Index(text[4:], "\n---")
+	if end < 0 {
+		return meta, strings.TrimSpace(text)
+	}
+	for _, line := range strings.Split(text[4:4+end], "\n") {
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		value := strings.TrimSpace(line[i+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		meta[strings.ToLower(strings.TrimSpace(line[:i]))] = value
+	}
+	body := text[4+end+4:]
+	if i := strings.Index(body, "\n"); i >= 0 && strings.TrimSpace(body[:i]) == "" {
+		// Rest of the closing `---` line
+		body = body[i+1:]
+	} else if i < 0 {
+		body = ""
+	}
+	return meta, strings.TrimSpace(body)
+}
+
+// userLocales returns the user's locale and its language (`pt_BR`, `pt`)
+// from the environment, most specific first.
+func userLocales() []string {
+	var locale string
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale = os.Getenv(name); locale != "" {
+			break
+		}
+	}
+	// Drop the encoding and modifier (`pt_BR.UTF-8@euro`)
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return nil
+	}
+	locales := []string{locale}
+	if i := strings.IndexAny(locale, "_-"); i > 0 {
+		locales = append(locales, locale[:i])
+	}
+	return locales
+}
+
+// chooseLocale picks the variant for the first matching locale, or the
+// default variant.
+func chooseLocale(files []helpFile, locales []string) helpFile {
+	for _, locale := range locales {
+		for _, file := range files {
+			if strings.EqualFold(strings.Replace(file.locale, "-", "_", 1), strings.Replace(locale, "-", "_", 1)) {
+				return file
+			}
+		}
+	}
+	for _, file := range files {
+		if file.locale == "" {
+			return file
+		}
+	}
+	return files[0]
+}
@@ -0,0 +1,105 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+
+//go:build go1.16
+// +build go1.16
+
+package cli_test
+
+import (
+	"os"
+	"testing/fstest"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Help files", func() {
+
+	var program *Program
+	var files fstest.MapFS
+	locale := map[string]*string{}
+
+	BeforeEach(func() {
+		for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			if value, ok := os.LookupEnv(name); ok {
+				locale[name] = &value
+			} else {
+				locale[name] = nil
+			}
+		}
+		os.Unsetenv("LC_ALL")
+		os.Unsetenv("LC_MESSAGES")
+		os.Setenv("LANG", "C")
+		program = New()
+		program.Command("capture", "capture packets")
+		files = fstest.MapFS{
+			"help/filters.md":    {Data: []byte("---\ntitle: Capture filters\ndescription: \"writing capture filters\"\n---\n\nUse `host` to filter.\n")},
+			"help/filters.fr.md": {Data: []byte("---\ntitle: Filtres\ndescription: écrire des filtres\n---\nUtilisez `host`.\n")},
+			"help/path.md":       {Data: []byte("The path is read from $CAPTURE_PATH.\n")},
+			"help/capture.md":    {Data: []byte("---\ncommand: capture\n---\nCaptures packets until stopped.\n")},
+			"help/notes.txt":     {Data: []byte("ignored")},
+		}
+	})
+
+	AfterEach(func() {
+		for name, value := range locale {
+			if value != nil {
+				os.Setenv(name, *value)
+			} else {
+				os.Unsetenv(name)
+			}
+		}
+	})
+
+	It("should register each file as a topic", func() {
+		Ω(program.LoadHelp(files, "help")).Should(Succeed())
+		Ω(program.Topics).Should(HaveLen(2))
+		Ω(program.Topics["filters"].Title).Should(Equal("Capture filters"))
+		Ω(program.Topics["filters"].Description).Should(Equal("writing capture filters"))
+		Ω(program.Topics["filters"].Body).Should(Equal("Use `host` to filter."))
+		Ω(program.Topics["filters"].Markdown).Should(BeTrue())
+		Ω(program.Topics["path"].Title).Should(Equal(""))
+		Ω(program.Topics["path"].Body).Should(Equal("The path is read from $CAPTURE_PATH."))
+	})
+
+	It("should set command bodies", func() {
+		Ω(program.LoadHelp(files, "help")).Should(Succeed())
+		Ω(program.Topics).ShouldNot(HaveKey("capture"))
+		Ω(program.Commands["capture"].Body).Should(Equal("Captures packets until stopped."))
+		Ω(program.Commands["capture"].Markdown).Should(BeTrue())
+		Ω(program.Commands["capture"].Description).Should(Equal("capture packets"))
+	})
+
+	It("should fail for unknown commands", func() {
+		files["help/status.md"] = &fstest.MapFile{Data: []byte("---\ncommand: status\n---\nStatus.\n")}
+		Ω(program.LoadHelp(files, "help")).ShouldNot(Succeed())
+	})
+
+	It("should choose the variant for the user's locale", func() {
+		os.Setenv("LANG", "fr_CA.UTF-8")
+		Ω(program.LoadHelp(files, "help")).Should(Succeed())
+		Ω(program.Topics["filters"].Title).Should(Equal("Filtres"))
+		Ω(program.Topics["filters"].Description).Should(Equal("écrire des filtres"))
+		Ω(program.Topics["path"].Body).Should(Equal("The path is read from $CAPTURE_PATH."))
+	})
+
+	It("should only treat known languages as locales", func() {
+		files["help/config.env.md"] = &fstest.MapFile{Data: []byte("Variables.\n")}
+		files["help/intro.faq.md"] = &fstest.MapFile{Data: []byte("Questions.\n")}
+		files["help/filters.pt_BR.md"] = &fstest.MapFile{Data: []byte("---\ntitle: Filtros\n---\nUse `host`.\n")}
+		os.Setenv("LANG", "pt_BR.UTF-8")
+		Ω(program.LoadHelp(files, "help")).Should(Succeed())
+		Ω(program.Topics).Should(HaveLen(4))
+		Ω(program.Topics["config.env"].Body).Should(Equal("Variables."))
+		Ω(program.Topics["intro.faq"].Body).Should(Equal("Questions."))
+		Ω(program.Topics["filters"].Title).Should(Equal("Filtros"))
+	})
+
+	It("should prefer $LC_ALL to $LANG", func() {
+		os.Setenv("LANG", "fr_FR.UTF-8")
+		os.Setenv("LC_ALL", "en_US.UTF-8")
+		Ω(program.LoadHelp(files, "help")).Should(Succeed())
+		Ω(program.Topics["filters"].Title).Should(Equal("Capture filters"))
+	})
+})
@@ -0,0 +1,346 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Log formats accepted by Terminal.LogFormat and the --log-format option.
+const (
+	LogFormatText   = "text"   // Human readable lines (default)
+	LogFormatJSON   = "json"   // One JSON object per line
+	LogFormatLogfmt = "logfmt" // One line of key=value pairs per record
+)
+
+// Level is the importance of a log message. Values match the log/slog
+// levels, with Trace and Verbose fitting in between.
+type Level int
+
+// Log levels from least to most important.
+const (
+	LevelTrace   Level = -8
+	LevelDebug   Level = -4
+	LevelVerbose Level = -2
+	LevelInfo    Level = 0
+	LevelWarn    Level = 4
+	LevelError   Level = 8
+)
+
+// levels lists the log levels in order; each -v or -q moves one step.
+var levels = []Level{LevelTrace, LevelDebug, LevelVerbose, LevelInfo, LevelWarn, LevelError}
+
+// String returns the lower case name of the level.
+func (l Level) String() string {
+	switch l {
+	case LevelTrace:
+		return "trace"
+	case LevelDebug:
+		return "debug"
+	case LevelVerbose:
+		return "verbose"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// prefix returns the label printed before messages of the level (if any)
+// and its style.
+func (l Level) prefix() (string, Style) {
+	switch {
+	case l <= LevelTrace:
+		return "trace: ", Dim
+	case l <= LevelDebug:
+		return "debug: ", Fg(Magenta)
+	case l >= LevelError:
+		return "error: ", NewStyle(Bold, Fg(Red))
+	case l >= LevelWarn:
+		return "warning: ", NewStyle(Bold, Fg(Yellow))
+	}
+	return "", Style{}
+}
+
+// Sets the base log level (LevelInfo by default). Each -v lowers and each -q
+// raises the level by one step from this base.
+func (t *Terminal) SetLevel(level Level) *Terminal {
+	t.Level = level
+	return t
+}
+
+// Sets the time format used to timestamp log messages ("" for none)
+func (t *Terminal) SetTimeFormat(format string) *Terminal {
+	t.TimeFormat = format
+	return t
+}
+
+// Sets the log format (LogFormatText, LogFormatJSON or LogFormatLogfmt),
+// overriding the --log-format option.
+func (t *Terminal) SetLogFormat(format string) *Terminal {
+	t.LogFormat = format
+	return t
+}
+
+// Returns true if log records are output in a structured (machine readable)
+// format.
+func (t *Terminal) Structured() bool {
+	return t.logFormat() != LogFormatText
+}
+
+// Returns the log format from the terminal setting or --log-format option.
+func (t *Terminal) logFormat() string {
+	format := t.LogFormat
+	if format == "" {
+		if t.Program != nil && t.Program.logFormat != nil {
+			format = t.Program.logFormat.Value
+		}
+	}
+	switch format {
+	case LogFormatJSON, LogFormatLogfmt:
+		return format
+	}
+	return LogFormatText
+}
+
+// Returns the lowest level that is output, taking the -v and -q options into
+// account.
+func (t *Terminal) Threshold() Level {
+	index := len(levels) - 1
+	for i, level := range levels {
+		if level >= t.Level {
+			index = i
+			break
+		}
+	}
+	if verbose := t.Program.OptionFor("--verbose"); verbose != nil {
+		index -= verbose.Count
+	}
+	if quiet := t.Program.OptionFor("--quiet"); quiet != nil {
+		index += quiet.Count
+	}
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(levels) {
+		index = len(levels) - 1
+	}
+	return levels[index]
+}
+
+// Returns true if messages at `level` are output
+func (t *Terminal) Enabled(level Level) bool {
+	return level >= t.Threshold()
+}
+
+// Outputs the message at the provided level with optional attributes given
+// as alternating keys and values (`"file", name, "size", 42`). Warnings and
+// errors go to stderr, everything else to stdout. In text format messages
+// are indented, labeled with their level (except info and verbose) and
+// timestamped if a time format is set; see LogFormat for structured formats.
+func (t *Terminal) Log(level Level, msg string, attrs ...interface{}) {
+	if !t.Enabled(level) {
+		return
+	}
+	out, color := t.Program.stdout(), t.ColorEnabled()
+	if level >= LevelWarn {
+		out, color = t.Program.stderr(), t.ErrColorEnabled()
+	}
+
+	var line strings.Builder
+	switch t.logFormat() {
+	case LogFormatJSON:
+		t.encodeJSON(&line, level, msg, attrs)
+	case LogFormatLogfmt:
+		t.encodeLogfmt(&line, level, msg, attrs)
+	default:
+		t.encodeText(&line, level, msg, attrs, color)
+	}
+	line.WriteString("\n")
+
+	// Keep a running spinner below the message
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.spinner != nil {
+		t.spinner.clear()
+		defer t.spinner.draw()
+	}
+	fmt.Fprint(out, line.String())
+}
+
+// Outputs the formatted message at the provided level
+func (t *Terminal) Logf(level Level, format string, data ...interface{}) {
+	if t.Enabled(level) {
+		t.Log(level, fmt.Sprintf(format, data...))
+	}
+}
+
+// Outputs the provided message only if the program is in trace mode (-vvv)
+func (t *Terminal) Trace(msg string) {
+	t.Log(LevelTrace, msg)
+}
+
+// Outputs the provided message only if the program is in trace mode (-vvv)
+func (t *Terminal) Tracef(format string, data ...interface{}) {
+	t.Logf(LevelTrace, format, data...)
+}
+
+// Outputs the provided message only if the program is in debug mode (-vv)
+func (t *Terminal) Debug(msg string) {
+	t.Log(LevelDebug, msg)
+}
+
+// Outputs the provided message only if the program is in debug mode (-vv)
+func (t *Terminal) Debugf(format string, data ...interface{}) {
+	t.Logf(LevelDebug, format, data...)
+}
+
+// Outputs the provided warning on stderr unless the program is very quiet (-qq)
+func (t *Terminal) Warn(msg string) {
+	t.Log(LevelWarn, msg)
+}
+
+// Outputs the provided warning on stderr unless the program is very quiet (-qq)
+func (t *Terminal) Warnf(format string, data ...interface{}) {
+	t.Logf(LevelWarn, format, data...)
+}
+
+// -------------------------------------------
+// Record encoding
+// -------------------------------------------
+
+// attr is a single key value pair attached to a log record.
+type attr struct {
+	key   string
+	value interface{}
+}
+
+// Converts alternating keys and values into attributes. A value without a
+// key is reported under "!BADKEY" (as log/slog does).
+func toAttrs(keyvals []interface{}) []attr {
+	var attrs []attr
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 == len(keyvals) {
+			attrs = append(attrs, attr{key: "!BADKEY", value: keyvals[i]})
+			break
+		}
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		attrs = append(attrs, attr{key: key, value: keyvals[i+1]})
+	}
+	return attrs
+}
+
+// Returns the record fields common to all structured formats followed by
+// the record attributes. Errors are expanded into their message and, when
+// they wrap other errors, the chain of messages under `<key>_chain`.
+func (t *Terminal) recordAttrs(level Level, msg string, keyvals []interface{}) []attr {
+	format := t.TimeFormat
+	if format == "" {
+		format = time.RFC3339Nano
+	}
+	attrs := []attr{
+		{key: "time", value: time.Now().Format(format)},
+		{key: "level", value: strings.ToUpper(level.String())},
+		{key: "msg", value: msg},
+		{key: "scope", value: t.Indent},
+	}
+	for _, a := range toAttrs(keyvals) {
+		err, ok := a.value.(error)
+		if !ok || err == nil {
+			attrs = append(attrs, a)
+			continue
+		}
+		attrs = append(attrs, attr{key: a.key, value: err.Error()})
+		if chain := errorChain(err); len(chain) > 1 {
+			attrs = append(attrs, attr{key: a.key + "_chain", value: chain})
+		}
+	}
+	return attrs
+}
+
+// Returns the messages of `err` and every error it wraps.
+func errorChain(err error) []string {
+	var chain []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, err.Error())
+	}
+	return chain
+}
+
+// Writes a human readable record with attributes as trailing key=value pairs.
+func (t *Terminal) encodeText(line *strings.Builder, level Level, msg string, keyvals []interface{}, color bool) {
+	if t.TimeFormat != "" {
+		line.WriteString(time.Now().Format(t.TimeFormat))
+		line.WriteString(" ")
+	}
+	line.WriteString(t.indentation())
+	if prefix, style := level.prefix(); prefix != "" {
+		if color {
+			prefix = style.Wrap(prefix)
+		}
+		line.WriteString(prefix)
+	}
+	line.WriteString(msg)
+	for _, a := range toAttrs(keyvals) {
+		line.WriteString(" ")
+		writeLogfmt(line, a)
+	}
+}
+
+// Writes a record as a single line JSON object.
+func (t *Terminal) encodeJSON(line *strings.Builder, level Level, msg string, keyvals []interface{}) {
+	line.WriteString("{")
+	for i, a := range t.recordAttrs(level, msg, keyvals) {
+		if i > 0 {
+			line.WriteString(",")
+		}
+		key, _ := json.Marshal(a.key)
+		line.Write(key)
+		line.WriteString(":")
+		value, err := json.Marshal(a.value)
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(a.value))
+		}
+		line.Write(value)
+	}
+	line.WriteString("}")
+}
+
+// Writes a record as logfmt key=value pairs.
+func (t *Terminal) encodeLogfmt(line *strings.Builder, level Level, msg string, keyvals []interface{}) {
+	for i, a := range t.recordAttrs(level, msg, keyvals) {
+		if i > 0 {
+			line.WriteString(" ")
+		}
+		writeLogfmt(line, a)
+	}
+}
+
+// Writes a key=value pair, quoting values that need it.
+func writeLogfmt(line *strings.Builder, a attr) {
+	var value string
+	switch v := a.value.(type) {
+	case []string:
+		value = strings.Join(v, ": ")
+	case error:
+		value = v.Error()
+	default:
+		value = fmt.Sprint(v)
+	}
+	line.WriteString(a.key)
+	line.WriteString("=")
+	if value == "" || strings.ContainsAny(value, " =\"\t\n") {
+		value = strconv.Quote(value)
+	}
+	line.WriteString(value)
+}
@@ -0,0 +1,78 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logging", func() {
+
+	var program *Program
+	var terminal *Terminal
+	var out, errOut bytes.Buffer
+
+	logAll := func() {
+		terminal.Trace("trace")
+		terminal.Debugf("debug %d", 1)
+		terminal.Verbose("verbose")
+		terminal.PushIndent().Info("info")
+		terminal.PopIndent()
+		terminal.Warn("warn")
+		terminal.Log(LevelError, "error")
+	}
+
+	BeforeEach(func() {
+		out.Reset()
+		errOut.Reset()
+		program = New()
+		program.Out, program.Err = &out, &errOut
+		program.Exit = func(int) {}
+		program.Command("run", "run the program")
+		terminal = program.Terminal
+	})
+
+	It("should log info and above by default", func() {
+		program.ParseArgs([]string{"tool", "run"})
+		logAll()
+		Ω(out.String()).Should(Equal("  info\n"))
+		Ω(errOut.String()).Should(Equal("warning: warn\nerror: error\n"))
+	})
+	It("should lower the threshold for each -v", func() {
+		program.ParseArgs([]string{"tool", "-vv", "run"})
+		Ω(terminal.Threshold()).Should(Equal(LevelDebug))
+		logAll()
+		Ω(out.String()).Should(Equal("debug: debug 1\nverbose\n  info\n"))
+	})
+	It("should raise the threshold for each -q", func() {
+		program.ParseArgs([]string{"tool", "--quiet", "-q", "run"})
+		Ω(terminal.Threshold()).Should(Equal(LevelError))
+		logAll()
+		Ω(out.String()).Should(BeEmpty())
+		Ω(errOut.String()).Should(Equal("error: error\n"))
+	})
+	It("should respect the base level", func() {
+		terminal.SetLevel(LevelTrace)
+		program.ParseArgs([]string{"tool", "-v", "run"})
+		Ω(terminal.Threshold()).Should(Equal(LevelTrace))
+		Ω(terminal.Enabled(LevelTrace)).Should(BeTrue())
+	})
+	It("should not replace a user defined -v option", func() {
+		program.Option("-v, --version", "display version")
+		program.ParseArgs([]string{"tool", "run"})
+		Ω(program.OptionFor("-v").Name).Should(Equal("version"))
+		Ω(program.OptionFor("--verbose").Short).Should(Equal(""))
+	})
+	It("should timestamp messages", func() {
+		terminal.SetTimeFormat("timestamp")
+		terminal.Info("info")
+		Ω(out.String()).Should(Equal("timestamp info\n"))
+	})
+	It("should color level labels on color terminals", func() {
+		terminal.SetColorMode(ColorAlways).Warn("careful")
+		Ω(errOut.String()).Should(Equal("\033[1;33mwarning: \033[0mcareful\n"))
+	})
+})
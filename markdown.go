@@ -0,0 +1,496 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Markdown block patterns.
+var (
+	mdHeading   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdRule      = regexp.MustCompile(`^ {0,3}((\*\s*){3,}|(-\s*){3,}|(_\s*){3,})$`)
+	mdListItem  = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	mdFence     = regexp.MustCompile("^\\s*(```|~~~)")
+	mdSeparator = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdSpecial   = regexp.MustCompile("[\\\\`*_\\[\\]<>|~&]")
+	mdBlockMark = regexp.MustCompile(`^([#=+-]|\d+[.)])`)
+)
+
+// mdSpan is inline text with a style.
+type mdSpan struct {
+	text  string
+	style Style
+}
+
+// Markdown renders a Markdown document for the terminal: headings, lists,
+// code blocks, block quotes, tables, emphasis, inline code and links are
+// styled when color is enabled (and shown as plain text otherwise), and text
+// is wrapped to the terminal width. The help command renders command and
+// topic bodies with Markdown when they are marked with SetMarkdown.
+func (t *Terminal) Markdown(source string) string {
+	width, _ := t.Size()
+	if width <= 0 {
+		width = 80
+	}
+	r := &mdRenderer{terminal: t, color: t.ColorEnabled(), width: width}
+	return r.render(source, "")
+}
+
+// mdRenderer renders Markdown blocks for the terminal.
+type mdRenderer struct {
+	terminal *Terminal
+	color    bool
+	width    int
+}
+
+// columns returns the width left after `prefix` (at least 1).
+func (r *mdRenderer) columns(prefix string) int {
+	if width := r.width - utf8.RuneCountInString(prefix); width > 1 {
+		return width
+	}
+	return 1
+}
+
+// render renders the blocks of `source`, starting every line with `prefix`.
+func (r *mdRenderer) render(source, prefix string) string {
+	lines := strings.Split(strings.Replace(source, "\r\n", "\n", -1), "\n")
+	var blocks []string
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			i++
+		case mdFence.MatchString(line):
+			fence := mdFence.FindStringSubmatch(line)[1]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			i++
+			blocks = append(blocks, r.code(code, prefix))
+		case mdHeading.MatchString(line):
+			match := mdHeading.FindStringSubmatch(line)
+			blocks = append(blocks, r.heading(len(match[1]), match[2], prefix))
+			i++
+		case mdRule.MatchString(line):
+			rule := strings.Repeat("─", r.columns(prefix))
+			if !r.color {
+				rule = strings.Repeat("-", r.columns(prefix))
+			}
+			blocks = append(blocks, prefix+rule+"\n")
+			i++
+		case strings.Contains(line, "|") && i+1 < len(lines) && mdSeparator.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			rows, separator := []string{line}, lines[i+1]
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				rows = append(rows, lines[i])
+			}
+			blocks = append(blocks, r.table(rows, separator, prefix))
+		case strings.HasPrefix(trimmed, ">"):
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				text := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, strings.TrimPrefix(text, " "))
+			}
+			bar := "> "
+			if r.color {
+				bar = Dim.Wrap("│") + " "
+			}
+			blocks = append(blocks, strings.TrimRight(r.render(strings.Join(quote, "\n"), prefix+bar), "\n")+"\n")
+		case mdListItem.MatchString(line):
+			var items []string
+			for ; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) == "" {
+					// Lists continue after a blank line with another item
+					if i+1 < len(lines) && mdListItem.MatchString(lines[i+1]) {
+						continue
+					}
+					break
+				}
+				if mdListItem.MatchString(lines[i]) || len(items) == 0 {
+					items = append(items, lines[i])
+				} else {
+					items[len(items)-1] += " " + strings.TrimSpace(lines[i])
+				}
+			}
+			blocks = append(blocks, r.list(items, prefix))
+		case strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"):
+			var code []string
+			for ; i < len(lines) && (strings.HasPrefix(lines[i], "    ") || strings.HasPrefix(lines[i], "\t") || strings.TrimSpace(lines[i]) == ""); i++ {
+				code = append(code, strings.TrimPrefix(strings.TrimPrefix(lines[i], "\t"), "    "))
+			}
+			for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+				code = code[:len(code)-1]
+			}
+			blocks = append(blocks, r.code(code, prefix))
+		default:
+			var paragraph []string
+			for ; i < len(lines) && r.paragraphLine(lines, i); i++ {
+				paragraph = append(paragraph, strings.TrimSpace(lines[i]))
+			}
+			if len(paragraph) == 0 {
+				paragraph = append(paragraph, trimmed)
+				i++
+			}
+			blocks = append(blocks, r.wrap(r.inline(strings.Join(paragraph, " ")), prefix, prefix))
+		}
+	}
+	return strings.Join(blocks, strings.TrimRight(prefix, " ")+"\n")
+}
+
+// paragraphLine returns true if line `i` continues a paragraph.
+func (r *mdRenderer) paragraphLine(lines []string, i int) bool {
+	line := lines[i]
+	if strings.TrimSpace(line) == "" {
+		return false
+	}
+	if len(lines) > i+1 && mdSeparator.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-") && strings.Contains(line, "|") {
+		return false
+	}
+	return !mdFence.MatchString(line) && !mdHeading.MatchString(line) && !mdRule.MatchString(line) &&
+		!mdListItem.MatchString(line) && !strings.HasPrefix(strings.TrimSpace(line), ">")
+}
+
+// heading renders a heading: styled with color, underlined for the first two
+// levels without.
+func (r *mdRenderer) heading(level int, text, prefix string) string {
+	spans := r.inline(text)
+	plain := spansText(spans)
+	if r.color {
+		style := NewStyle(Bold, Italic)
+		switch level {
+		case 1:
+			style = NewStyle(Bold, Underline)
+		case 2:
+			style = Bold
+		}
+		return prefix + style.Wrap(plain) + "\n"
+	}
+	switch level {
+	case 1:
+		return prefix + plain + "\n" + prefix + strings.Repeat("=", utf8.RuneCountInString(plain)) + "\n"
+	case 2:
+		return prefix + plain + "\n" + prefix + strings.Repeat("-", utf8.RuneCountInString(plain)) + "\n"
+	}
+	return prefix + plain + "\n"
+}
+
+// code renders code lines indented and unwrapped.
+func (r *mdRenderer) code(lines []string, prefix string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		if r.color {
+			line = Fg(Cyan).Wrap(line)
+		}
+		b.WriteString(prefix + "    " + line + "\n")
+	}
+	return b.String()
+}
+
+// list renders list items with bullets or numbers, nesting items by their
+// indentation.
+func (r *mdRenderer) list(items []string, prefix string) string {
+	var b strings.Builder
+	for _, item := range items {
+		match := mdListItem.FindStringSubmatch(item)
+		indent := strings.Repeat("  ", len(strings.Replace(match[1], "\t", "    ", -1))/2)
+		marker := match[2]
+		if !unicode.IsDigit(rune(marker[0])) {
+			marker = "-"
+			if r.color {
+				marker = "•"
+			}
+		}
+		first := prefix + indent + marker + " "
+		rest := prefix + indent + strings.Repeat(" ", utf8.RuneCountInString(marker)+1)
+		b.WriteString(r.wrap(r.inline(match[3]), first, rest))
+	}
+	return b.String()
+}
+
+// table renders a pipe table with the terminal table renderer, aligning
+// columns as marked in the separator row.
+func (r *mdRenderer) table(rows []string, separator, prefix string) string {
+	cells := func(row string) []string {
+		row = strings.TrimSpace(row)
+		row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+		parts := strings.Split(row, "|")
+		for i, part := range parts {
+			parts[i] = spansText(r.inline(strings.TrimSpace(part)))
+		}
+		return parts
+	}
+	table := r.terminal.Table(cells(rows[0])...).SetWidth(r.columns(prefix))
+	for i, marker := range cells(separator) {
+		switch {
+		case strings.HasPrefix(marker, ":") && strings.HasSuffix(marker, ":"):
+			table.SetAlign(i, AlignCenter)
+		case strings.HasSuffix(marker, ":"):
+			table.SetAlign(i, AlignRight)
+		}
+	}
+	for _, row := range rows[1:] {
+		var values []interface{}
+		for _, cell := range cells(row) {
+			values = append(values, cell)
+		}
+		table.Row(values...)
+	}
+	if r.color {
+		table.SetBorder(BorderUnicode)
+	}
+	return prefix + strings.Replace(strings.TrimRight(table.String(), "\n"), "\n", "\n"+prefix, -1) + "\n"
+}
+
+// wrap lays out inline spans in lines no wider than the renderer width.
+// `first` starts the first line and `rest` the following lines.
+func (r *mdRenderer) wrap(spans []mdSpan, first, rest string) string {
+	var b strings.Builder
+	line, lineWidth := first, utf8.RuneCountInString(first)
+	start := true  // No words on the line yet
+	space := false // The previous span ended with a space
+	for _, span := range spans {
+		for i, word := range strings.Fields(span.text) {
+			// A word continues the previous span's word when nothing separates them
+			glued := i == 0 && !start && !space && !unicode.IsSpace(rune(span.text[0]))
+			width := utf8.RuneCountInString(word)
+			if !start && !glued && lineWidth+1+width > r.width {
+				b.WriteString(line + "\n")
+				line, lineWidth, start = rest, utf8.RuneCountInString(rest), true
+			}
+			if !start && !glued {
+				line += " "
+				lineWidth++
+			}
+			if r.color && !span.style.IsZero() {
+				line += span.style.Wrap(word)
+			} else {
+				line += word
+			}
+			lineWidth += width
+			start = false
+		}
+		if span.text != "" {
+			space = unicode.IsSpace(rune(span.text[len(span.text)-1]))
+		}
+	}
+	b.WriteString(line + "\n")
+	return b.String()
+}
+
+// inline parses emphasis, inline code and links into styled spans.
+func (r *mdRenderer) inline(text string) []mdSpan {
+	var spans []mdSpan
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			spans = append(spans, mdSpan{text: plain.String()})
+			plain.Reset()
+		}
+	}
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text):
+			plain.WriteByte(text[i+1])
+			i += 2
+			continue
+		case c == '`':
+			if end := strings.IndexByte(text[i+1:], '`'); end >= 0 {
+				flush()
+				spans = append(spans, mdSpan{text: text[i+1 : i+1+end], style: Fg(Cyan)})
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(text[i:], "**") || strings.HasPrefix(text[i:], "__"):
+			marker := text[i : i+2]
+			if end := strings.Index(text[i+2:], marker); end > 0 {
+				flush()
+				spans = append(spans, mdSpan{text: text[i+2 : i+2+end], style: Bold})
+				i += end + 4
+				continue
+			}
+		case (c == '*' || c == '_') && (i == 0 || !isWordByte(text[i-1])):
+			if end := strings.IndexByte(text[i+1:], c); end > 0 {
+				flush()
+				spans = append(spans, mdSpan{text: text[i+1 : i+1+end], style: Italic})
+				i += end + 2
+				continue
+			}
+		case c == '[':
+			if close := strings.Index(text[i:], "]("); close > 0 {
+				if end := strings.IndexByte(text[i+close:], ')'); end > 0 {
+					label := text[i+1 : i+close]
+					url := text[i+close+2 : i+close+end]
+					flush()
+					spans = append(spans, mdSpan{text: label, style: Underline})
+					if url != label {
+						spans = append(spans, mdSpan{text: " (" + url + ")", style: Dim})
+					}
+					i += close + end + 1
+					continue
+				}
+			}
+		case c == '<':
+			if end := strings.IndexByte(text[i:], '>'); end > 0 && strings.Contains(text[i:i+end], "://") {
+				flush()
+				spans = append(spans, mdSpan{text: text[i+1 : i+end], style: Underline})
+				i += end + 1
+				continue
+			}
+		}
+		plain.WriteByte(c)
+		i++
+	}
+	flush()
+	return spans
+}
+
+// isWordByte returns true for ASCII letters, digits and underscores.
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// spansText returns the text of spans without styles.
+func spansText(spans []mdSpan) string {
+	var b strings.Builder
+	for _, span := range spans {
+		b.WriteString(span.text)
+	}
+	return b.String()
+}
+
+// -------------------------------------------
+// Documentation export
+// -------------------------------------------
+
+// WriteMarkdown writes reference documentation for the program in Markdown:
+// usage, global options, commands with their options and bodies, and help
+// topics. Markdown bodies (see SetMarkdown) are written as-is; other bodies
+// and descriptions are escaped so they read as they do in help.
+func (p *Program) WriteMarkdown(w io.Writer) error {
+	p.implicitOptions()
+	p.describeOutputFormats()
+	var b strings.Builder
+	name := p.Exe
+	if p.Name != "" {
+		name = p.Name
+	}
+	fmt.Fprintf(&b, "# %s\n\n", name)
+	if p.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", markdownEscape(p.Description))
+	}
+	fmt.Fprintf(&b, "## Usage\n\n```\n%s [options] <command>\n```\n\n", p.Exe)
+	if len(p.Options) > 0 {
+		var options []*Option
+		for _, option := range p.Options {
+			options = append(options, option)
+		}
+		sort.Slice(options, func(i, j int) bool { return options[i].Flags < options[j].Flags })
+		b.WriteString("## Global options\n\n")
+		writeOptionsMarkdown(&b, options)
+	}
+
+	var names []string
+	for name := range p.Commands {
+		if name != "*" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		b.WriteString("## Commands\n\n")
+	}
+	for _, name := range names {
+		command := p.Commands[name]
+		fmt.Fprintf(&b, "### `%s`\n\n", command.Flags)
+		if command.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", markdownEscape(command.Description))
+		}
+		if command.Body != "" {
+			fmt.Fprintf(&b, "%s\n\n", markdownBody(command.Body, command.Markdown))
+		}
+		if len(command.Options) > 0 {
+			writeOptionsMarkdown(&b, command.Options)
+		}
+	}
+
+	names = names[:0]
+	for name := range p.Topics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		b.WriteString("## Topics\n\n")
+	}
+	for _, name := range names {
+		topic := p.Topics[name]
+		title := topic.Topic
+		if topic.Title != "" {
+			title = topic.Title
+		}
+		fmt.Fprintf(&b, "### %s\n\n", markdownEscape(title))
+		if topic.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", markdownEscape(topic.Description))
+		}
+		if topic.Body != "" {
+			fmt.Fprintf(&b, "%s\n\n", markdownBody(topic.Body, topic.Markdown))
+		}
+	}
+	_, err := io.WriteString(w, strings.TrimRight(b.String(), "\n")+"\n")
+	return err
+}
+
+// markdownBody returns a command or topic body for the exported
+// documentation, escaping it unless it is Markdown.
+func markdownBody(body string, markdown bool) string {
+	body = strings.TrimSpace(body)
+	if markdown {
+		return body
+	}
+	return markdownEscape(body)
+}
+
+// markdownEscape escapes plain text so Markdown displays it as written:
+// markup characters are escaped and line breaks and indentation are kept.
+func markdownEscape(text string) string {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			lines[i] = ""
+			continue
+		}
+		indent := strings.Replace(line[:len(line)-len(trimmed)], "\t", "    ", -1)
+		trimmed = mdSpecial.ReplaceAllString(trimmed, `\$0`)
+		if mark := mdBlockMark.FindString(trimmed); mark != "" {
+			// Escape the last character of a heading or list marker
+			trimmed = mark[:len(mark)-1] + `\` + trimmed[len(mark)-1:]
+		}
+		lines[i] = strings.Repeat("&nbsp;", len(indent)) + trimmed
+		if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			// Hard line break
+			lines[i] += `\`
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeOptionsMarkdown writes options as a Markdown table.
+func writeOptionsMarkdown(b *strings.Builder, options []*Option) {
+	b.WriteString("| Option | Description |\n|---|---|\n")
+	for _, option := range options {
+		description := option.Description
+		if option.Default != "" {
+			description += fmt.Sprintf(" (defaults to `%s`)", option.Default)
+		}
+		fmt.Fprintf(b, "| `%s` | %s |\n", option.Flags, strings.Replace(description, "|", "\\|", -1))
+	}
+	b.WriteString("\n")
+}
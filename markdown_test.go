@@ -0,0 +1,128 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+	"os"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Markdown", func() {
+
+	var program *Program
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		out.Reset()
+		program = New()
+		program.Out = &out
+		os.Setenv("COLUMNS", "40")
+	})
+	AfterEach(func() {
+		os.Unsetenv("COLUMNS")
+	})
+
+	It("should render plain text when color is disabled", func() {
+		Ω(program.Terminal.Markdown("" +
+			"# Filters\n" +
+			"\n" +
+			"Capture filters select the **packets** to keep, see\n" +
+			"[the manual](https://example.com/filters) for `tcpdump` syntax.\n" +
+			"\n" +
+			"## Examples\n" +
+			"\n" +
+			"- by host\n" +
+			"  - nested\n" +
+			"1. by port\n" +
+			"\n" +
+			"```\n" +
+			"tool tcp 80 --filter 'host a'\n" +
+			"```\n" +
+			"\n" +
+			"> quoted *text*\n")).Should(Equal("" +
+			"Filters\n" +
+			"=======\n" +
+			"\n" +
+			"Capture filters select the packets to\n" +
+			"keep, see the manual\n" +
+			"(https://example.com/filters) for\n" +
+			"tcpdump syntax.\n" +
+			"\n" +
+			"Examples\n" +
+			"--------\n" +
+			"\n" +
+			"- by host\n" +
+			"  - nested\n" +
+			"1. by port\n" +
+			"\n" +
+			"    tool tcp 80 --filter 'host a'\n" +
+			"\n" +
+			"> quoted text\n"))
+	})
+	It("should style text when color is enabled", func() {
+		program.Terminal.SetColorMode(ColorAlways)
+		Ω(program.Terminal.Markdown("## Usage\n\nRun `tool`, *carefully*.\n\n- item\n")).Should(Equal("" +
+			"\033[1mUsage\033[0m\n" +
+			"\n" +
+			"Run \033[36mtool\033[0m, \033[3mcarefully\033[0m.\n" +
+			"\n" +
+			"• item\n"))
+	})
+	It("should render tables", func() {
+		Ω(program.Terminal.Markdown("| Key | Value |\n|---|---:|\n| a | 1 |\n| bb | 22 |\n")).Should(Equal("" +
+			"Key  Value\n" +
+			"a        1\n" +
+			"bb      22\n"))
+	})
+	It("should render command and topic bodies in help", func() {
+		program.Topic("filters", "capture filters").SetBody("Use **host** filters.").SetMarkdown(true)
+		program.ParseArgs([]string{"exe", "help", "filters"})
+		Ω(out.String()).Should(HaveSuffix("\nUse host filters.\n"))
+	})
+	It("should print plain bodies as written", func() {
+		body := "Examples:\n  tool tcp 80     capture port 80\n  tool tcp 443    capture *args to 2*3*4"
+		program.Command("tcp <port>", "capture TCP packets").SetBody(body)
+		program.ParseArgs([]string{"exe", "help", "tcp"})
+		Ω(out.String()).Should(HaveSuffix("\n" + body + "\n"))
+	})
+	It("should render rules in narrow terminals", func() {
+		os.Setenv("COLUMNS", "3")
+		defer os.Unsetenv("COLUMNS")
+		Ω(program.Terminal.Markdown("> > > ---")).Should(Equal("> > > -\n"))
+	})
+	It("should export documentation as Markdown", func() {
+		program.Exe = "tool"
+		program.SetDescription("Network tool")
+		program.Command("tcp <port>", "capture TCP packets").
+			SetBody("Captures on **port**.").SetMarkdown(true).
+			Option("-H, --host <host>", "host to capture", "localhost")
+		program.Topic("filters", "capture filters").SetBody("Use `host` filters.").SetMarkdown(true)
+		Ω(program.WriteMarkdown(&out)).Should(Succeed())
+		Ω(out.String()).Should(HavePrefix("# tool\n\nNetwork tool\n\n## Usage\n\n```\ntool [options] <command>\n```\n\n## Global options\n"))
+		Ω(out.String()).Should(ContainSubstring("" +
+			"## Commands\n\n" +
+			"### `tcp <port>`\n\n" +
+			"capture TCP packets\n\n" +
+			"Captures on **port**.\n\n" +
+			"| Option | Description |\n" +
+			"|---|---|\n" +
+			"| `-H, --host <host>` | host to capture (defaults to `localhost`) |\n"))
+		Ω(out.String()).Should(HaveSuffix("## Topics\n\n### filters\n\ncapture filters\n\nUse `host` filters.\n"))
+	})
+	It("should escape plain bodies and use topic titles when exporting", func() {
+		program.Exe = "tool"
+		program.Command("tcp <port>", "capture TCP packets").
+			SetBody("# Examples:\n  tool tcp 80    capture *args to <port>\n- done")
+		program.Topic("filters", "capture filters").SetTitle("Capture filters").SetBody("Use host_name.")
+		Ω(program.WriteMarkdown(&out)).Should(Succeed())
+		Ω(out.String()).Should(ContainSubstring("" +
+			"capture TCP packets\n\n" +
+			"\\# Examples:\\\n" +
+			"&nbsp;&nbsp;tool tcp 80    capture \\*args to \\<port\\>\\\n" +
+			"\\- done\n\n"))
+		Ω(out.String()).Should(HaveSuffix("## Topics\n\n### Capture filters\n\ncapture filters\n\nUse host\\_name.\n"))
+	})
+})
@@ -0,0 +1,395 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// OutputAction is implemented by functions that return data for the program
+// to render rather than printing it themselves. See Command.SetOutputAction.
+type OutputAction func(program *Program, command *Command, unknownArgs []string) (interface{}, error)
+
+// Encoder writes `value` to `w` in an output format. `arg` is the text after
+// `=` in the format name (for example the template in `template={{.Name}}`).
+type Encoder func(w io.Writer, value interface{}, arg string) error
+
+// SetOutputAction sets an action that returns data, and registers the
+// `-o, --output <format>` option on the command. The returned value is
+// rendered with the encoder for the chosen format (`defaultFormat` or
+// "table" when the option isn't given). Built in formats are json, yaml,
+// table, csv, tsv and template=<go template>; more can be added with
+// Program.Encoder.
+func (c *Command) SetOutputAction(action OutputAction, defaultFormat ...string) *Command {
+	format := "table"
+	if len(defaultFormat) > 0 {
+		format = defaultFormat[0]
+	}
+	c.Option("-o, --output <format>", "output format: "+strings.Join(c.Program.encoderNames(), ", "), format)
+	c.output = c.Options[len(c.Options)-1]
+	return c.SetAction(func(program *Program, command *Command, unknownArgs []string) {
+		format := command.output
+		// Check the format before the action runs
+		if _, _, err := program.formatEncoder(format.Value, format.Default); err != nil {
+			fmt.Fprintf(program.stderr(), "\n  error: %v\n\n", err)
+			program.exit(1)
+			return
+		}
+		value, err := action(program, command, unknownArgs)
+		if err != nil {
+			program.Terminal.Error(err, "")
+			return
+		}
+		if err := program.Render(value, format.Value, format.Default); err != nil {
+			fmt.Fprintf(program.stderr(), "\n  error: %v\n\n", err)
+			program.exit(1)
+		}
+	})
+}
+
+// describeOutputFormats lists the currently available formats in the help
+// of the --output options added by SetOutputAction, so encoders registered
+// later are included.
+func (p *Program) describeOutputFormats() {
+	description := "output format: " + strings.Join(p.encoderNames(), ", ")
+	for _, command := range p.Commands {
+		if command.output != nil {
+			command.output.Description = description
+		}
+	}
+}
+
+// Encoder registers (or replaces) the encoder for an output format.
+func (p *Program) Encoder(format string, encoder Encoder) *Program {
+	if p.Encoders == nil {
+		p.Encoders = map[string]Encoder{}
+	}
+	p.Encoders[format] = encoder
+	return p
+}
+
+// Render writes `value` to the program output using the encoder for the
+// first non-empty `formats` entry. A format may carry an argument after `=`
+// (`template={{.Name}}`).
+func (p *Program) Render(value interface{}, formats ...string) error {
+	encoder, arg, err := p.formatEncoder(formats...)
+	if err != nil {
+		return err
+	}
+	return encoder(p.stdout(), value, arg)
+}
+
+// formatEncoder returns the encoder and argument for the first non-empty
+// `formats` entry, or an error for an unknown format.
+func (p *Program) formatEncoder(formats ...string) (Encoder, string, error) {
+	format := ""
+	for _, f := range formats {
+		if f != "" {
+			format = f
+			break
+		}
+	}
+	name, arg := format, ""
+	if i := strings.Index(format, "="); i >= 0 {
+		name, arg = format[:i], format[i+1:]
+	}
+	encoder := p.encoder(name)
+	if encoder == nil {
+		return nil, "", fmt.Errorf("unknown output format `%s`, expected one of: %s", name, strings.Join(p.encoderNames(), ", "))
+	}
+	return encoder, arg, nil
+}
+
+// encoder returns the registered or built in encoder for a format.
+func (p *Program) encoder(name string) Encoder {
+	if encoder, ok := p.Encoders[name]; ok {
+		return encoder
+	}
+	return p.builtinEncoder(name)
+}
+
+// builtinEncoder returns the built in encoder for a format (if any).
+func (p *Program) builtinEncoder(name string) Encoder {
+	switch name {
+	case "json":
+		return EncodeJSON
+	case "yaml":
+		return EncodeYAML
+	case "template":
+		return EncodeTemplate
+	case TableFormatText, TableFormatCSV, TableFormatTSV:
+		return func(w io.Writer, value interface{}, _ string) error {
+			return p.Terminal.TableOf(value).Render(w, name)
+		}
+	}
+	return nil
+}
+
+// encoderNames lists the available output formats.
+func (p *Program) encoderNames() []string {
+	var custom []string
+	for name := range p.Encoders {
+		if p.encoder(name) != nil && p.builtinEncoder(name) == nil {
+			custom = append(custom, name)
+		}
+	}
+	sort.Strings(custom)
+	return append([]string{"json", "yaml", "table", "csv", "tsv", "template=<template>"}, custom...)
+}
+
+// EncodeJSON writes `value` as indented JSON.
+func EncodeJSON(w io.Writer, value interface{}, _ string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// EncodeTemplate executes the Go template `text` with `value` as its data,
+// ending the output with a newline.
+func EncodeTemplate(w io.Writer, value interface{}, text string) error {
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(w, value); err != nil {
+		return err
+	}
+	if !strings.HasSuffix(text, "\n") {
+		_, err = io.WriteString(w, "\n")
+	}
+	return err
+}
+
+// EncodeYAML writes `value` as YAML. The value is converted using its JSON
+// representation, so `json` struct tags apply.
+func EncodeYAML(w io.Writer, value interface{}, _ string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return err
+	}
+	var b strings.Builder
+	writeYAML(&b, generic, 0, false)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// writeYAML writes a JSON decoded value as YAML at `indent` levels. `inline`
+// is true when the value follows a key or list marker on the same line.
+func writeYAML(b *strings.Builder, value interface{}, indent int, inline bool) {
+	prefix := strings.Repeat("  ", indent)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		if inline {
+			b.WriteString("\n")
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			b.WriteString(prefix + yamlScalar(key) + ":")
+			writeYAML(b, v[key], indent+1, true)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		if inline {
+			b.WriteString("\n")
+		}
+		for _, item := range v {
+			// Collections start on the same line as their list marker
+			var nested strings.Builder
+			writeYAML(&nested, item, indent+1, false)
+			if text := nested.String(); strings.HasPrefix(text, prefix+"  ") {
+				b.WriteString(prefix + "- " + strings.TrimPrefix(text, prefix+"  "))
+				continue
+			}
+			b.WriteString(prefix + "-")
+			writeYAML(b, item, indent+1, true)
+		}
+	default:
+		if inline {
+			b.WriteString(" ")
+		}
+		b.WriteString(yamlScalar(v) + "\n")
+	}
+}
+
+// yamlScalar formats a scalar, quoting strings YAML would misread.
+func yamlScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		plain := v != "" && !strings.ContainsAny(v, ":#{}[],&*?|<>=!%@`'\"\n\t\\") &&
+			strings.TrimSpace(v) == v && !strings.HasPrefix(v, "-")
+		if plain {
+			switch strings.ToLower(v) {
+			case "true", "false", "yes", "no", "on", "off", "null", "~":
+				plain = false
+			}
+			if _, err := strconv.ParseFloat(v, 64); err == nil {
+				plain = false
+			}
+		}
+		if plain {
+			return v
+		}
+		return strconv.Quote(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// -------------------------------------------
+// Tables from data
+// -------------------------------------------
+
+// TableOf builds a table from a value: a slice of structs (or a single
+// struct) becomes a row per element with a column per exported field, a
+// slice of maps a column per key, and anything else a single cell. Struct
+// columns are named with the `table` field tag (`table:"NAME"`), or the field
+// name, and tagged `table:"-"` to be skipped.
+func (t *Terminal) TableOf(value interface{}) *Table {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	var items []reflect.Value
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, v.Index(i))
+		}
+	} else if v.IsValid() {
+		items = []reflect.Value{v}
+	}
+
+	element := elementType(v)
+	switch {
+	case element != nil && element.Kind() == reflect.Struct:
+		var fields []int
+		var headers []string
+		for i := 0; i < element.NumField(); i++ {
+			field := element.Field(i)
+			name := field.Tag.Get("table")
+			if field.PkgPath != "" || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fields = append(fields, i)
+			headers = append(headers, name)
+		}
+		table := t.Table(headers...)
+		for _, item := range items {
+			item = indirect(item)
+			var cells []interface{}
+			for _, i := range fields {
+				if item.IsValid() {
+					cells = append(cells, item.Field(i).Interface())
+				} else {
+					cells = append(cells, "")
+				}
+			}
+			table.Row(cells...)
+		}
+		return table
+	case element != nil && element.Kind() == reflect.Map:
+		var headers []string
+		for i, item := range items {
+			items[i] = indirect(item)
+		}
+		for _, item := range items {
+			if !item.IsValid() {
+				continue
+			}
+			for _, key := range item.MapKeys() {
+				if header := fmt.Sprint(key.Interface()); !contains(headers, header) {
+					headers = append(headers, header)
+				}
+			}
+		}
+		sort.Strings(headers)
+		table := t.Table(headers...)
+		for _, item := range items {
+			cells := make([]interface{}, len(headers))
+			for i, header := range headers {
+				cells[i] = ""
+				if !item.IsValid() {
+					continue
+				}
+				for _, key := range item.MapKeys() {
+					if fmt.Sprint(key.Interface()) == header {
+						cells[i] = item.MapIndex(key).Interface()
+					}
+				}
+			}
+			table.Row(cells...)
+		}
+		return table
+	}
+	table := t.Table()
+	for _, item := range items {
+		table.Row(item.Interface())
+	}
+	return table
+}
+
+// elementType returns the type of the rows in `v` (nil if unknown).
+func elementType(v reflect.Value) reflect.Type {
+	if !v.IsValid() {
+		return nil
+	}
+	element := v.Type()
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		element = element.Elem()
+	}
+	for element.Kind() == reflect.Ptr {
+		element = element.Elem()
+	}
+	return element
+}
+
+// indirect follows pointers and interfaces to the underlying value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// contains returns true if `values` includes `value`.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
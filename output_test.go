@@ -0,0 +1,82 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	. "github.com/gopackage/cli"
+	"github.com/gopackage/cli/clitest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type device struct {
+	Name   string `table:"NAME" json:"name"`
+	Port   int    `table:"PORT" json:"port"`
+	Secret string `table:"-" json:"-"`
+}
+
+var _ = Describe("Output formats", func() {
+
+	var program *Program
+	var calls int
+
+	BeforeEach(func() {
+		calls = 0
+		program = New()
+		program.Command("devices", "list devices").
+			SetOutputAction(func(program *Program, command *Command, unknownArgs []string) (interface{}, error) {
+				calls++
+				return []device{{"router", 80, "x"}, {"switch: core", 8080, "y"}}, nil
+			})
+	})
+
+	run := func(args ...string) *clitest.Result {
+		result, err := clitest.Run(program, clitest.Invocation{Args: append([]string{"tool"}, args...)})
+		Ω(err).ShouldNot(HaveOccurred())
+		return result
+	}
+
+	It("should render a table by default using field tags", func() {
+		Ω(run("devices").Stdout).Should(Equal("" +
+			"NAME          PORT\n" +
+			"router        80\n" +
+			"switch: core  8080\n"))
+	})
+	It("should render JSON, YAML, CSV and templates", func() {
+		Ω(run("devices", "-o", "json").Stdout).Should(MatchJSON(`[{"name":"router","port":80},{"name":"switch: core","port":8080}]`))
+		Ω(run("devices", "--output", "yaml").Stdout).Should(Equal("" +
+			"- name: router\n" +
+			"  port: 80\n" +
+			"- name: \"switch: core\"\n" +
+			"  port: 8080\n"))
+		Ω(run("devices", "-o", "csv").Stdout).Should(Equal("NAME,PORT\nrouter,80\nswitch: core,8080\n"))
+		Ω(run("devices", "--output=template={{range .}}{{.Name}}={{.Port}} {{end}}").Stdout).
+			Should(Equal("router=80 switch: core=8080 \n"))
+	})
+	It("should fail on unknown formats", func() {
+		result := run("devices", "-o", "xml")
+		Ω(result.ExitCode).Should(Equal(1))
+		Ω(result.Stderr).Should(ContainSubstring("unknown output format `xml`"))
+		Ω(calls).Should(Equal(0))
+	})
+	It("should use custom encoders", func() {
+		program.Encoder("names", func(w io.Writer, value interface{}, _ string) error {
+			for _, d := range value.([]device) {
+				fmt.Fprintln(w, d.Name)
+			}
+			return nil
+		})
+		Ω(run("devices", "-o", "names").Stdout).Should(Equal("router\nswitch: core\n"))
+		Ω(run("help", "--tree").Stdout).Should(ContainSubstring("template=<template>, names"))
+	})
+	It("should build tables from maps and scalars", func() {
+		var out bytes.Buffer
+		rows := []map[string]interface{}{{"b": 2, "a": 1}, {"a": 3}}
+		Ω(program.Terminal.TableOf(rows).Render(&out, "csv")).Should(Succeed())
+		Ω(out.String()).Should(Equal("a,b\n1,2\n3,\n"))
+		Ω(program.Terminal.TableOf([]string{"x", "y"}).String()).Should(Equal("x\ny\n"))
+	})
+})
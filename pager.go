@@ -0,0 +1,153 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// DefaultPager is the pager command used when $PAGER isn't set. The flags
+// make less exit when the output fits on one screen (-F), pass colors through
+// (-R) and leave the output on the screen when it exits (-X).
+const DefaultPager = "less -FRX"
+
+// Page calls `write` and displays the output through the $PAGER command
+// (DefaultPager if unset) when stdout is a terminal and the output is taller
+// than the screen. Otherwise the output is written to stdout directly. Paging
+// is disabled by the --no-pager option or an empty $PAGER. Output is streamed
+// to the pager as it is written once it no longer fits the screen, and is
+// flushed if the program exits while paging.
+func (t *Terminal) Page(write func(w io.Writer)) error {
+	out := t.Program.stdout()
+	if _, paging := out.(*pagerWriter); paging || !t.pagerEnabled() {
+		write(out)
+		return nil
+	}
+	pager := t.pager()
+	if len(pager) == 0 {
+		write(out)
+		return nil
+	}
+	_, rows := t.Size()
+	w := &pagerWriter{terminal: t, command: pager, out: out, rows: rows}
+	t.mu.Lock()
+	previous := t.paging
+	t.paging = w
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.paging = previous
+		t.mu.Unlock()
+	}()
+	write(w)
+	return w.Close()
+}
+
+// Sets the command to display its output through a pager (see
+// Terminal.Page).
+func (c *Command) SetPager(pager bool) *Command {
+	c.Pager = pager
+	return c
+}
+
+// pagerEnabled returns true if output may be paged: stdout is a terminal
+// and --no-pager isn't set.
+func (t *Terminal) pagerEnabled() bool {
+	if option := t.Program.noPager; option != nil && option.Value != "" {
+		return false
+	}
+	return writerIsTerminal(t.Program.stdout())
+}
+
+// pager returns the pager command and arguments (empty to disable paging).
+func (t *Terminal) pager() []string {
+	pager, ok := os.LookupEnv("PAGER")
+	if !ok {
+		pager = DefaultPager
+	}
+	args, err := SplitArgs(pager)
+	if err != nil || (len(args) == 1 && args[0] == "cat") {
+		return nil
+	}
+	return args
+}
+
+// pagerWriter sends output to a pager. Output is held back until it is
+// taller than `rows` (or immediately when the height is unknown), then the
+// pager is started and everything written is streamed to it. It reports
+// being a terminal so color detection treats it like stdout.
+type pagerWriter struct {
+	terminal *Terminal
+	command  []string
+	out      io.Writer
+	rows     int
+
+	buffer bytes.Buffer   // Output before the pager starts
+	lines  int            // Lines in buffer
+	cmd    *exec.Cmd      // Running pager (if started)
+	stdin  io.WriteCloser // Pager input
+	direct bool           // Write to out (the pager failed to start)
+	closed bool
+}
+
+func (w *pagerWriter) Write(p []byte) (int, error) {
+	switch {
+	case w.closed || w.direct:
+		return w.out.Write(p)
+	case w.stdin != nil:
+		return w.stdin.Write(p)
+	}
+	w.buffer.Write(p)
+	w.lines += bytes.Count(p, []byte("\n"))
+	if w.rows <= 0 || w.lines >= w.rows {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// start runs the pager and sends it the buffered output, or writes the
+// output directly if the pager can't be started.
+func (w *pagerWriter) start() error {
+	cmd := exec.Command(w.command[0], w.command[1:]...)
+	cmd.Stdout = w.out
+	cmd.Stderr = w.terminal.Program.stderr()
+	stdin, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		// Without a working pager the output is still shown
+		w.direct = true
+		_, err = w.out.Write(w.buffer.Bytes())
+		w.buffer.Reset()
+		return err
+	}
+	w.cmd, w.stdin = cmd, stdin
+	_, err = stdin.Write(w.buffer.Bytes())
+	w.buffer.Reset()
+	return err
+}
+
+// Close writes output that fit the screen to stdout, or ends the pager
+// input and waits for the pager to exit.
+func (w *pagerWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.cmd == nil {
+		_, err := w.out.Write(w.buffer.Bytes())
+		w.buffer.Reset()
+		return err
+	}
+	w.stdin.Close()
+	return w.cmd.Wait()
+}
+
+func (w *pagerWriter) isTerminal() bool {
+	return true
+}
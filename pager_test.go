@@ -0,0 +1,118 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pager", func() {
+
+	var program *Program
+	var out *pageBuffer // Reports being a terminal
+	var environment map[string]*string
+
+	BeforeEach(func() {
+		environment = map[string]*string{}
+		for _, key := range []string{"PAGER", "LINES"} {
+			if value, ok := os.LookupEnv(key); ok {
+				environment[key] = &value
+			} else {
+				environment[key] = nil
+			}
+		}
+		out = &pageBuffer{}
+		program = New()
+		program.Out = out
+		program.Exit = func(code int) {}
+		os.Setenv("PAGER", "tr a-z A-Z")
+		os.Unsetenv("LINES")
+	})
+	AfterEach(func() {
+		for key, value := range environment {
+			if value != nil {
+				os.Setenv(key, *value)
+			} else {
+				os.Unsetenv(key)
+			}
+		}
+	})
+
+	page := func(w io.Writer) {
+		fmt.Fprintln(w, "long output")
+	}
+
+	It("should pipe output through $PAGER", func() {
+		Ω(program.Terminal.Page(page)).Should(Succeed())
+		Ω(out.String()).Should(Equal("LONG OUTPUT\n"))
+	})
+	It("should write output that fits the screen directly", func() {
+		os.Setenv("LINES", "24")
+		Ω(program.Terminal.Page(page)).Should(Succeed())
+		Ω(out.String()).Should(Equal("long output\n"))
+	})
+	It("should not page with --no-pager or an empty $PAGER", func() {
+		program.Command("status", "show status")
+		program.ParseArgs([]string{"exe", "--no-pager", "status"})
+		Ω(program.Terminal.Page(page)).Should(Succeed())
+		Ω(out.String()).Should(Equal("long output\n"))
+
+		out.Reset()
+		program.OptionFor("--no-pager").Value = ""
+		os.Setenv("PAGER", "")
+		Ω(program.Terminal.Page(page)).Should(Succeed())
+		Ω(out.String()).Should(Equal("long output\n"))
+	})
+	It("should page help and commands that opt in", func() {
+		program.Command("log", "show the log").SetPager(true).
+			SetAction(func(program *Program, command *Command, unknownArgs []string) {
+				program.Terminal.Info("entry")
+			})
+		program.ParseArgs([]string{"exe", "log"})
+		Ω(out.String()).Should(Equal("ENTRY\n"))
+
+		out.Reset()
+		program.ParseArgs([]string{"exe", "help"})
+		Ω(out.String()).Should(ContainSubstring("USAGE: EXE"))
+	})
+	It("should stream output once it no longer fits the screen", func() {
+		os.Setenv("LINES", "2")
+		var started []bool
+		program.Command("log", "show the log").SetPager(true).
+			SetAction(func(program *Program, command *Command, unknownArgs []string) {
+				for _, entry := range []string{"one", "two", "three"} {
+					program.Terminal.Info(entry)
+					started = append(started, program.Terminal.paging.cmd != nil)
+				}
+			})
+		program.ParseArgs([]string{"exe", "log"})
+		Ω(started).Should(Equal([]bool{false, true, true}))
+		Ω(out.String()).Should(Equal("ONE\nTWO\nTHREE\n"))
+	})
+	It("should flush paged output when the program exits", func() {
+		var shown string
+		program.Exit = func(code int) { shown = out.String() }
+		program.Command("log", "show the log").SetPager(true).
+			SetAction(func(program *Program, command *Command, unknownArgs []string) {
+				program.Terminal.Info("entry")
+				program.Help()
+			})
+		program.ParseArgs([]string{"exe", "log"})
+		Ω(shown).Should(HavePrefix("ENTRY\n"))
+	})
+})
+
+// pageBuffer collects test output. It reports being a terminal so output is
+// paged.
+type pageBuffer struct {
+	bytes.Buffer
+}
+
+func (b *pageBuffer) isTerminal() bool {
+	return true
+}
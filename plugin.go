@@ -0,0 +1,327 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DescribeArg is the argument passed to a plugin to request its metadata.
+const DescribeArg = "__describe"
+
+// DescribeMarker opts a plugin into the describe handshake when it appears
+// in the first kilobyte of the executable, for example as a `# cli-describe`
+// comment at the top of a script. Plugins without it are never run to
+// describe themselves.
+const DescribeMarker = "cli-describe"
+
+// DescribeManifest is the suffix of a file next to a plugin holding its
+// description (`tool-deploy.describe.json` for `tool-deploy`), which is read
+// instead of running the plugin.
+const DescribeManifest = ".describe.json"
+
+// errNoDescription is returned for plugins that don't describe themselves.
+var errNoDescription = errors.New("plugin has no description")
+
+// DescribeTimeout bounds how long a plugin may take to describe itself.
+var DescribeTimeout = 5 * time.Second
+
+var (
+	// argSeparator splits the plugin `args` description into arguments.
+	argSeparator = regexp.MustCompile(` +`)
+	// argSpec matches a required (`<name>`) or optional (`[name]`) argument.
+	argSpec = regexp.MustCompile(`^(<[^<>\[\] ]+>|\[[^<>\[\] ]+\])$`)
+	// envInvalid matches characters that aren't valid in variable names.
+	envInvalid = regexp.MustCompile(`[^A-Z0-9_]`)
+)
+
+// PluginDescription is the JSON document a plugin prints to stdout when
+// invoked with DescribeArg (if it has the DescribeMarker) or provides in a
+// DescribeManifest file. Other plugins are still runnable but are listed
+// without metadata.
+//
+//	{
+//	  "description": "deploy the application",
+//	  "body": "Long form help text.",
+//	  "args": "<env> [version]",
+//	  "options": [{"flags": "-f, --force", "description": "skip checks"}],
+//	  "topics": [{"topic": "environments", "description": "deploy targets"}]
+//	}
+type PluginDescription struct {
+	Description string         `json:"description"`
+	Body        string         `json:"body,omitempty"`
+	Args        string         `json:"args,omitempty"`
+	Options     []PluginOption `json:"options,omitempty"`
+	Topics      []PluginTopic  `json:"topics,omitempty"`
+}
+
+// PluginOption describes an option accepted by a plugin.
+type PluginOption struct {
+	Flags       string `json:"flags"`
+	Description string `json:"description"`
+	Default     string `json:"default,omitempty"`
+}
+
+// PluginTopic describes a help topic contributed by a plugin.
+type PluginTopic struct {
+	Topic       string `json:"topic"`
+	Description string `json:"description"`
+	Body        string `json:"body,omitempty"`
+}
+
+// EnablePlugins turns on discovery of external sub-commands. Any executable
+// named `<exe>-<name>` found in `dirs`, next to the program binary or on
+// $PATH becomes the command `<name>`, git-style. Commands registered with
+// Command() always take precedence over plugins.
+func (p *Program) EnablePlugins(dirs ...string) *Program {
+	p.PluginsEnabled = true
+	p.PluginDirs = append(p.PluginDirs, dirs...)
+	return p
+}
+
+// IsPlugin returns true if the command runs an external plugin executable.
+func (c *Command) IsPlugin() bool {
+	if c.Program == nil {
+		return false
+	}
+	_, ok := c.Program.Execs[c.Command]
+	return ok
+}
+
+// DescribePlugins reads the metadata of every discovered plugin that opts in
+// (see DescribeMarker and DescribeManifest) and merges it into the plugin
+// commands and program topics. It is used by help, search and completion;
+// plugins run as commands aren't described and check their own arguments.
+// Plugins are only described once per run, so later help displays and
+// searches reuse the metadata; failures leave the plugin listed without
+// metadata.
+func (p *Program) DescribePlugins() {
+	for name := range p.Execs {
+		p.describePlugin(p.Commands[name])
+	}
+}
+
+// describePlugin reads the description of a single plugin command.
+func (p *Program) describePlugin(command *Command) {
+	if command == nil || command.described {
+		return
+	}
+	command.described = true
+
+	data, err := p.pluginDescription(p.Execs[command.Command])
+	if err != nil {
+		return
+	}
+	var description PluginDescription
+	if err := json.Unmarshal(data, &description); err != nil {
+		return
+	}
+	if !description.valid() {
+		// Malformed metadata: keep the plugin as a plain command
+		return
+	}
+
+	command.Description = description.Description
+	command.Body = description.Body
+	if args := strings.TrimSpace(description.Args); args != "" {
+		command.Flags = command.Command + " " + args
+		command.parseExpectedArgs(argSeparator.Split(args, -1))
+	}
+	for _, option := range description.Options {
+		command.Option(option.Flags, option.Description, option.Default)
+	}
+	for _, topic := range description.Topics {
+		if _, ok := p.Topics[topic.Topic]; ok {
+			continue
+		}
+		p.Topic(topic.Topic, topic.Description).SetBody(topic.Body)
+	}
+}
+
+// pluginDescription returns the description document of the plugin `bin`
+// from its manifest or, if the plugin has the describe marker, by running
+// the describe handshake.
+func (p *Program) pluginDescription(bin string) ([]byte, error) {
+	if data, err := ioutil.ReadFile(bin + DescribeManifest); err == nil {
+		return data, nil
+	}
+	file, err := os.Open(bin)
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, 1024)
+	n, _ := io.ReadFull(file, head)
+	file.Close()
+	if !bytes.Contains(head[:n], []byte(DescribeMarker)) {
+		return nil, errNoDescription
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DescribeTimeout)
+	defer cancel()
+	var out bytes.Buffer
+	proc := exec.CommandContext(ctx, bin, DescribeArg)
+	proc.Stdout = &out
+	proc.Env = p.pluginEnv()
+	if err := proc.Run(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// valid returns true if the arguments and options of the description can be
+// registered: arguments are `<required>` then `[optional]` names and options
+// have flags.
+func (d PluginDescription) valid() bool {
+	if args := strings.TrimSpace(d.Args); args != "" {
+		optional := false
+		for _, arg := range argSeparator.Split(args, -1) {
+			if !argSpec.MatchString(arg) || (optional && arg[0] == '<') {
+				return false
+			}
+			optional = arg[0] == '['
+		}
+	}
+	for _, option := range d.Options {
+		o := NewOption(nil, option.Flags, option.Description)
+		if len(o.Short) < 2 && len(o.Long) < 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// pluginEnv returns the environment for plugin processes: the current
+// environment plus the value of every global option as `<EXE>_<NAME>`, named
+// after the long flag (for example `--dry-run` on `tool` becomes
+// `TOOL_DRY_RUN` and `--no-pager` becomes `TOOL_NO_PAGER`). The verbosity
+// options pass the number of times they were given (`-vv` is 2).
+func (p *Program) pluginEnv() []string {
+	env := os.Environ()
+	verbose, quiet := p.OptionFor("--verbose"), p.OptionFor("--quiet")
+	for _, option := range p.Options {
+		value := option.Value
+		if value == "" {
+			value = option.Default
+		}
+		if (option == verbose || option == quiet) && option.Count > 0 {
+			value = strconv.Itoa(option.Count)
+		}
+		name := strings.TrimPrefix(option.Long, "--")
+		if name == "" || value == "" {
+			continue
+		}
+		env = append(env, envName(p.Exe, name)+"="+value)
+	}
+	return env
+}
+
+// envName builds an environment variable name from its parts, upper casing
+// them and replacing characters that aren't valid in variable names.
+func envName(parts ...string) string {
+	name := strings.ToUpper(strings.Join(parts, "_"))
+	return envInvalid.ReplaceAllString(name, "_")
+}
+
+// pluginSearchPath lists the directories searched for plugins in priority
+// order: configured plugin dirs, the directory of `argv0`, then $PATH.
+func (p *Program) pluginSearchPath(argv0 string) []string {
+	dirs := append([]string{}, p.PluginDirs...)
+	if strings.ContainsRune(argv0, os.PathSeparator) {
+		dirs = append(dirs, filepath.Dir(argv0))
+	}
+	return append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+}
+
+// discoverPlugins registers a command for every plugin executable found on
+// the plugin search path.
+func (p *Program) discoverPlugins(argv0 string) {
+	if p.Execs == nil {
+		p.Execs = map[string]string{}
+	}
+	prefix := p.Exe + "-"
+	for _, dir := range p.pluginSearchPath(argv0) {
+		if dir == "" {
+			dir = "."
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			name := file.Name()
+			if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) || strings.HasSuffix(name, DescribeManifest) {
+				continue
+			}
+			// Follow symlinks (the usual layout for package managers)
+			info, err := os.Stat(filepath.Join(dir, name))
+			if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+			command := name[len(prefix):]
+			if _, ok := p.Commands[command]; ok {
+				// Earlier directories and built in commands win
+				continue
+			}
+			p.Execs[command] = filepath.Join(dir, name)
+			p.Commands[command] = NewCommand(p, command, "")
+		}
+	}
+}
+
+// pluginArgs returns the raw arguments following the plugin command in
+// `argv` so the plugin receives them exactly as the user typed them. The
+// command is the normalized argument at `index`, which came from the raw
+// argument `sources[index]`.
+func pluginArgs(argv []string, sources []int, index int) []string {
+	if index < 0 || index >= len(sources) {
+		return nil
+	}
+	return argv[sources[index]+1:]
+}
+
+// executeSubCommand runs the plugin executable for `command` connected to the
+// program streams. A failing plugin exits the program with the plugin's exit
+// code.
+func (p *Program) executeSubCommand(command *Command, args []string) {
+	bin := p.Execs[command.Command]
+	proc := exec.Command(bin, args...)
+	proc.Stdout = p.stdout()
+	proc.Stderr = p.stderr()
+	proc.Stdin = p.stdin()
+	proc.Env = p.pluginEnv()
+	p.RunningCommand = proc
+	if err := proc.Run(); err != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case errors.As(err, &exitErr):
+			// The plugin reports its own errors
+			code := exitErr.ExitCode()
+			if code < 0 {
+				// Terminated by a signal
+				code = 1
+			}
+			p.exit(code)
+		case errors.Is(err, os.ErrNotExist):
+			fmt.Fprintf(p.stderr(), "\n  error: %s(1) does not exist, try --help\n\n", filepath.Base(bin))
+			p.exit(127)
+		case errors.Is(err, os.ErrPermission):
+			fmt.Fprintf(p.stderr(), "\n  error: %s(1) not executable, try chmod or run with root\n\n", filepath.Base(bin))
+			p.exit(126)
+		default:
+			fmt.Fprintf(p.stderr(), "\n  error: running %s(1) failed: %v\n\n", filepath.Base(bin), err)
+			p.exit(1)
+		}
+	}
+}
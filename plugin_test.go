@@ -0,0 +1,183 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/gopackage/cli"
+	"github.com/gopackage/cli/clitest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Plugins", func() {
+
+	var dir string
+	var program *Program
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "cli-plugins")
+		Ω(err).ShouldNot(HaveOccurred())
+		script := "#!/bin/sh\necho \"hello $*\"\nexit 3\n"
+		Ω(ioutil.WriteFile(filepath.Join(dir, "tool-hello"), []byte(script), 0755)).Should(Succeed())
+		Ω(ioutil.WriteFile(filepath.Join(dir, "tool-notes"), []byte("notes"), 0644)).Should(Succeed())
+		describe := `#!/bin/sh
+# cli-describe
+if [ "$1" = "__describe" ]; then
+  echo described >> "$(dirname "$0")/describe.log"
+  echo '{"description": "deploy the app", "args": "<env>", "options": [{"flags": "-f, --force", "description": "skip checks"}], "topics": [{"topic": "targets", "description": "deploy targets"}]}'
+  exit 0
+fi
+echo "deploying $1 verbose=$TOOL_VERBOSE"
+`
+		Ω(ioutil.WriteFile(filepath.Join(dir, "tool-deploy"), []byte(describe), 0755)).Should(Succeed())
+		env := "#!/bin/sh\necho \"pager=$TOOL_PAGER no_pager=$TOOL_NO_PAGER verbose=$TOOL_VERBOSE\"\n"
+		Ω(ioutil.WriteFile(filepath.Join(dir, "tool-env"), []byte(env), 0755)).Should(Succeed())
+
+		program = New().EnablePlugins(dir)
+		program.Option("-v, --verbose", "display verbose information")
+		program.Command("tcp <port>", "capture TCP packets on <port>")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should discover executable plugins as commands", func() {
+		result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "help"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(program.Commands).Should(HaveKey("hello"))
+		Ω(program.Commands).ShouldNot(HaveKey("notes"))
+		Ω(program.Commands["hello"].IsPlugin()).Should(BeTrue())
+		Ω(result.Stdout).Should(MatchRegexp(`(?s)Plugins:.* hello +tool-hello\n`))
+	})
+	It("should discover symlinked plugins", func() {
+		target, err := ioutil.TempDir("", "cli-plugin-target")
+		Ω(err).ShouldNot(HaveOccurred())
+		defer os.RemoveAll(target)
+		script := "#!/bin/sh\necho \"linked $*\"\n"
+		Ω(ioutil.WriteFile(filepath.Join(target, "linked"), []byte(script), 0755)).Should(Succeed())
+		Ω(os.Symlink(filepath.Join(target, "linked"), filepath.Join(dir, "tool-linked"))).Should(Succeed())
+		Ω(os.Symlink(filepath.Join(target, "missing"), filepath.Join(dir, "tool-broken"))).Should(Succeed())
+
+		result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "linked", "x"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Stdout).Should(Equal("linked x\n"))
+		Ω(program.Commands).ShouldNot(HaveKey("broken"))
+	})
+	It("should pass raw arguments and propagate the exit code", func() {
+		result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "hello", "-abc", "world"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Stdout).Should(Equal("hello -abc world\n"))
+		Ω(result.ExitCode).Should(Equal(3))
+	})
+	It("should pass the arguments after the command, not after an option value", func() {
+		program.Option("--target <name>", "target to use")
+		result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "--target", "hello", "hello", "-abc"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Stdout).Should(Equal("hello -abc\n"))
+		result, err = clitest.Run(program, clitest.Invocation{Args: []string{"tool", "-v", "--target=hello", "hello", "x"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Stdout).Should(Equal("hello x\n"))
+	})
+	It("should report missing plugin executables", func() {
+		_, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "tcp", "80"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		// Remove the plugin after discovery
+		os.Remove(filepath.Join(dir, "tool-hello"))
+		program.PluginsEnabled = false
+		result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "hello"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.ExitCode).Should(Equal(127))
+		Ω(result.Stderr).Should(ContainSubstring("tool-hello(1) does not exist"))
+	})
+	It("should describe plugins supporting the describe handshake", func() {
+		result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "help"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		deploy := program.Commands["deploy"]
+		Ω(deploy.Flags).Should(Equal("deploy <env>"))
+		Ω(deploy.Description).Should(Equal("deploy the app"))
+		Ω(deploy.ArgFor("env").Required).Should(BeTrue())
+		Ω(deploy.OptionFor("--force")).ShouldNot(BeNil())
+		Ω(program.Topics).Should(HaveKey("targets"))
+		Ω(result.Stdout).Should(ContainSubstring("deploy <env>   deploy the app"))
+	})
+	It("should ignore malformed plugin descriptions", func() {
+		for name, args := range map[string]string{"bad": "<", "order": "[a] <b>", "word": "<env> extra"} {
+			manifest := `{"description": "broken", "args": "` + args + `"}`
+			Ω(ioutil.WriteFile(filepath.Join(dir, "tool-"+name), []byte("#!/bin/sh\n"), 0755)).Should(Succeed())
+			Ω(ioutil.WriteFile(filepath.Join(dir, "tool-"+name+DescribeManifest), []byte(manifest), 0755)).Should(Succeed())
+		}
+		result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "help"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Exited).Should(BeFalse())
+		for _, name := range []string{"bad", "order", "word"} {
+			Ω(program.Commands[name].Flags).Should(Equal(name))
+			Ω(program.Commands[name].Description).Should(Equal(""))
+			Ω(program.Commands[name].Args).Should(BeEmpty())
+		}
+	})
+	It("should print first class help for described plugins", func() {
+		result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "help", "deploy"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Stdout).Should(Equal("Usage: tool [options] deploy <env>\n\ndeploy the app\n"))
+	})
+	It("should describe plugins once per run", func() {
+		for i := 0; i < 2; i++ {
+			_, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "help"}})
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+		_, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "help", "--search", "deploy"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		log, err := ioutil.ReadFile(filepath.Join(dir, "describe.log"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(log)).Should(Equal("described\n"))
+	})
+	It("should run plugins without describing them", func() {
+		for i := 0; i < 2; i++ {
+			result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "deploy"}})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(result.ExitCode).Should(Equal(0))
+			Ω(result.Stdout).Should(Equal("deploying  verbose=\n"))
+			// Described by help, the plugin still checks its own arguments
+			_, err = clitest.Run(program, clitest.Invocation{Args: []string{"tool", "help", "deploy"}})
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+		log, err := ioutil.ReadFile(filepath.Join(dir, "describe.log"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(log)).Should(Equal("described\n"))
+	})
+	It("should only describe plugins that opt in", func() {
+		legacy := "#!/bin/sh\necho \"$*\" >> \"$(dirname \"$0\")/legacy.log\"\n"
+		Ω(ioutil.WriteFile(filepath.Join(dir, "tool-legacy"), []byte(legacy), 0755)).Should(Succeed())
+		for _, args := range [][]string{{"tool", "help"}, {"tool", "help", "--tree"}, {"tool", "help", "--search", "legacy"}} {
+			_, err := clitest.Run(program, clitest.Invocation{Args: args})
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+		program.Complete("legacy --")
+		program.Complete("help ")
+		Ω(filepath.Join(dir, "legacy.log")).ShouldNot(BeAnExistingFile())
+		Ω(program.Commands["deploy"].Description).Should(Equal("deploy the app"))
+	})
+	It("should read plugin manifests instead of running plugins", func() {
+		manifest := `{"description": "greet someone", "args": "[name]"}`
+		Ω(ioutil.WriteFile(filepath.Join(dir, "tool-hello"+DescribeManifest), []byte(manifest), 0644)).Should(Succeed())
+		result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "help"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Stdout).Should(ContainSubstring("hello [name]   greet someone"))
+		Ω(program.Commands).ShouldNot(HaveKey("hello" + DescribeManifest))
+	})
+	It("should pass global options to plugins as environment variables", func() {
+		result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "-v", "deploy", "prod"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Stdout).Should(Equal("deploying prod verbose=1\n"))
+	})
+	It("should name variables after negated flags and count verbosity", func() {
+		result, err := clitest.Run(program, clitest.Invocation{Args: []string{"tool", "--no-pager", "-vv", "env"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Stdout).Should(Equal("pager= no_pager=true verbose=2\n"))
+	})
+})
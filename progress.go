@@ -0,0 +1,278 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress is a progress bar showing the completed percentage, rate and
+// estimated time remaining of an operation. Create bars with
+// Terminal.Progress (or MultiProgress.Progress to show several at once),
+// report work with Add or Set (or io.Copy into the bar) and finish with Done.
+// Bars are safe to update from several goroutines.
+//
+// When cursor control is disabled as the bars are created (output isn't a
+// terminal, see Terminal.CursorEnabled) the bar is printed as a plain line
+// every PlainInterval and when it is done.
+type Progress struct {
+	Label         string        // Text shown before the bar
+	Total         int64         // Total amount of work (0 if unknown)
+	Width         int           // Width of the bar in characters
+	Bytes         bool          // Show amounts and rates as byte sizes
+	PlainInterval time.Duration // Time between plain line updates
+
+	group   *MultiProgress
+	current int64
+	start   time.Time
+	printed time.Time // Last plain line update
+	done    bool
+}
+
+// MultiProgress displays several progress bars, one per line, redrawing them
+// together as they are updated from different goroutines.
+type MultiProgress struct {
+	Terminal        *Terminal
+	RefreshInterval time.Duration // Minimum time between redraws
+
+	mu     sync.Mutex
+	bars   []*Progress
+	cursor bool      // Cursor control enabled when the bars were created
+	lines  int       // Lines drawn by the last redraw
+	drawn  time.Time // Time of the last redraw
+}
+
+// Creates a progress bar for `total` units of work and displays it.
+func (t *Terminal) Progress(total int64) *Progress {
+	return t.MultiProgress().Progress(total)
+}
+
+// Creates a container displaying several progress bars at once.
+func (t *Terminal) MultiProgress() *MultiProgress {
+	return &MultiProgress{
+		Terminal:        t,
+		RefreshInterval: 100 * time.Millisecond,
+		cursor:          t.CursorEnabled(),
+	}
+}
+
+// Progress adds a bar for `total` units of work below the existing bars.
+func (m *MultiProgress) Progress(total int64) *Progress {
+	bar := &Progress{
+		Total:         total,
+		Width:         30,
+		PlainInterval: 5 * time.Second,
+		group:         m,
+		start:         time.Now(),
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bar.printed = bar.start
+	m.bars = append(m.bars, bar)
+	m.render(bar, true)
+	return bar
+}
+
+// Done marks every bar done and displays them a final time.
+func (m *MultiProgress) Done() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, bar := range m.bars {
+		if !bar.done {
+			bar.done = true
+			m.render(bar, true)
+		}
+	}
+}
+
+// render displays the bars after `changed` was updated. `force` skips the
+// redraw rate limit. Must be called with the lock held.
+func (m *MultiProgress) render(changed *Progress, force bool) {
+	now := time.Now()
+	if !m.due(changed, force, now) {
+		return
+	}
+
+	// Keep a running spinner below the bars
+	t := m.Terminal
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.spinner != nil {
+		t.spinner.clear()
+		defer t.spinner.draw()
+	}
+	if !m.cursor {
+		t.Print(changed.line(now)).Nl()
+		return
+	}
+	if m.lines > 0 {
+		t.Up(m.lines)
+	}
+	for _, bar := range m.bars {
+		t.Print("\r").ClearLine().Print(bar.line(now)).Nl()
+	}
+	m.lines = len(m.bars)
+}
+
+// due reports whether the bars should be displayed at `now` and records the
+// update. Must be called with the lock held.
+func (m *MultiProgress) due(changed *Progress, force bool, now time.Time) bool {
+	if !m.cursor {
+		if force && changed.done || now.Sub(changed.printed) >= changed.PlainInterval {
+			changed.printed = now
+			return true
+		}
+		return false
+	}
+	if !force && now.Sub(m.drawn) < m.RefreshInterval {
+		return false
+	}
+	m.drawn = now
+	return true
+}
+
+// Sets the label shown before the bar.
+func (p *Progress) SetLabel(label string) *Progress {
+	p.group.mu.Lock()
+	defer p.group.mu.Unlock()
+	p.Label = label
+	p.group.render(p, true)
+	return p
+}
+
+// Sets the width of the bar in characters.
+func (p *Progress) SetWidth(width int) *Progress {
+	p.group.mu.Lock()
+	defer p.group.mu.Unlock()
+	p.Width = width
+	return p
+}
+
+// Shows amounts and rates as byte sizes (KB, MB...).
+func (p *Progress) SetBytes(bytes bool) *Progress {
+	p.group.mu.Lock()
+	defer p.group.mu.Unlock()
+	p.Bytes = bytes
+	return p
+}
+
+// Adds `n` units of completed work.
+func (p *Progress) Add(n int64) *Progress {
+	p.group.mu.Lock()
+	defer p.group.mu.Unlock()
+	return p.set(p.current + n)
+}
+
+// Sets the amount of completed work.
+func (p *Progress) Set(n int64) *Progress {
+	p.group.mu.Lock()
+	defer p.group.mu.Unlock()
+	return p.set(n)
+}
+
+// Write counts the bytes written as completed work, so a bar can be the
+// destination of io.Copy or an io.MultiWriter.
+func (p *Progress) Write(data []byte) (int, error) {
+	p.Add(int64(len(data)))
+	return len(data), nil
+}
+
+// Done marks the bar complete and displays it a final time.
+func (p *Progress) Done() {
+	p.group.mu.Lock()
+	defer p.group.mu.Unlock()
+	if p.done {
+		return
+	}
+	p.done = true
+	p.group.render(p, true)
+}
+
+// Current returns the amount of completed work.
+func (p *Progress) Current() int64 {
+	p.group.mu.Lock()
+	defer p.group.mu.Unlock()
+	return p.current
+}
+
+// set updates the completed work. Must be called with the lock held.
+func (p *Progress) set(n int64) *Progress {
+	if p.done {
+		return p
+	}
+	p.current = n
+	p.group.render(p, p.Total > 0 && n >= p.Total)
+	return p
+}
+
+// line formats the bar as of `now`: label, bar, percentage, amounts, rate
+// and time remaining.
+func (p *Progress) line(now time.Time) string {
+	var parts []string
+	if p.Label != "" {
+		parts = append(parts, p.Label)
+	}
+	elapsed := now.Sub(p.start).Seconds()
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(p.current) / elapsed
+	}
+	if p.Total > 0 {
+		fraction := float64(p.current) / float64(p.Total)
+		if fraction > 1 {
+			fraction = 1
+		}
+		if p.Width > 0 && p.group.cursor {
+			filled := int(fraction * float64(p.Width))
+			bar := strings.Repeat("=", filled)
+			if filled < p.Width {
+				bar += ">" + strings.Repeat(" ", p.Width-filled-1)
+			}
+			parts = append(parts, "["+bar+"]")
+		}
+		parts = append(parts, fmt.Sprintf("%3.0f%%", fraction*100),
+			fmt.Sprintf("(%s/%s)", p.amount(float64(p.current)), p.amount(float64(p.Total))))
+	} else {
+		parts = append(parts, p.amount(float64(p.current)))
+	}
+	parts = append(parts, p.amount(rate)+"/s")
+	switch {
+	case p.done:
+		parts = append(parts, "in "+formatDuration(now.Sub(p.start)))
+	case p.Total > 0 && rate > 0:
+		remaining := float64(p.Total-p.current) / rate
+		parts = append(parts, "ETA "+formatDuration(time.Duration(remaining*float64(time.Second))))
+	}
+	return strings.Join(parts, " ")
+}
+
+// amount formats an amount of work (or a rate), as a byte size if requested.
+func (p *Progress) amount(n float64) string {
+	if !p.Bytes {
+		if n == float64(int64(n)) {
+			return fmt.Sprintf("%d", int64(n))
+		}
+		return fmt.Sprintf("%.1f", n)
+	}
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	unit := 0
+	for n >= 1024 && unit < len(units)-1 {
+		n /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f%s", n, units[unit])
+	}
+	return fmt.Sprintf("%.1f%s", n, units[unit])
+}
+
+// formatDuration formats a duration rounded to the second (or tenth of a
+// second under a minute).
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
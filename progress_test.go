@@ -0,0 +1,99 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Progress", func() {
+
+	var program *Program
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		out.Reset()
+		program = New()
+		program.Out = &out
+	})
+
+	It("should print a plain line when done if output isn't a terminal", func() {
+		bar := program.Terminal.Progress(10).SetLabel("copy")
+		bar.Add(4).Add(6)
+		Ω(out.String()).Should(BeEmpty())
+		bar.Done()
+		bar.Done()
+		Ω(out.String()).Should(MatchRegexp(`^copy 100% \(10/10\) [0-9.]+/s in [0-9.]+m?s\n$`))
+	})
+	It("should count bytes copied into the bar", func() {
+		bar := program.Terminal.Progress(2048).SetBytes(true)
+		_, err := io.Copy(bar, strings.NewReader(strings.Repeat("x", 1024)))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(bar.Current()).Should(Equal(int64(1024)))
+		bar.Done()
+		Ω(out.String()).Should(ContainSubstring(" 50% (1.0KB/2.0KB)"))
+	})
+	It("should redraw the bars in place when cursor control is enabled", func() {
+		program.Terminal.SetColorMode(ColorAlways)
+		bars := program.Terminal.MultiProgress()
+		first := bars.Progress(4).SetWidth(4)
+		second := bars.Progress(2).SetWidth(4)
+		first.Add(2)
+		second.Add(2)
+		bars.Done()
+		lines := strings.Split(out.String(), "\n")
+		Ω(lines[len(lines)-3]).Should(ContainSubstring("\033[2A\r\033[2K[==> ]  50% (2/4)"))
+		Ω(lines[len(lines)-2]).Should(ContainSubstring("[====] 100% (2/2)"))
+	})
+	It("should be safe to update from several goroutines", func() {
+		bars := program.Terminal.MultiProgress()
+		var wg sync.WaitGroup
+		var progress []*Progress
+		for i := 0; i < 4; i++ {
+			bar := bars.Progress(100)
+			progress = append(progress, bar)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					bar.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		bars.Done()
+		for _, bar := range progress {
+			Ω(bar.Current()).Should(Equal(int64(100)))
+		}
+		Ω(strings.Count(out.String(), "100% (100/100)")).Should(Equal(4))
+	})
+	It("should serialize redraws with log output and setters", func() {
+		program.Terminal.SetColorMode(ColorAlways)
+		bars := program.Terminal.MultiProgress()
+		bar := bars.Progress(100)
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				bar.Add(1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				bar.SetWidth(10 + i).SetBytes(i%2 == 0)
+				program.Terminal.Info("copying")
+			}
+		}()
+		wg.Wait()
+		bars.Done()
+		Ω(bar.Current()).Should(Equal(int64(100)))
+	})
+})
@@ -0,0 +1,400 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNoAnswer is returned by prompts when input isn't interactive and no
+// answer was provided by an option or piped input.
+var ErrNoAnswer = errors.New("no answer available")
+
+// Validator checks a prompt answer, returning an error describing why an
+// invalid answer was rejected.
+type Validator func(answer string) error
+
+// Asks a yes/no question. An empty answer selects `defaultValue`. If an
+// `answer` option is provided and set, its value is used instead of asking.
+func (t *Terminal) Confirm(message string, defaultValue bool, answer ...*Option) (bool, error) {
+	hint := "[y/N]"
+	if defaultValue {
+		hint = "[Y/n]"
+	}
+	var result bool
+	_, err := t.ask(message+" "+hint, "", func(text string) error {
+		switch strings.ToLower(strings.TrimSpace(text)) {
+		case "":
+			result = defaultValue
+		case "y", "yes", "true":
+			result = true
+		case "n", "no", "false":
+			result = false
+		default:
+			return fmt.Errorf("please answer yes or no")
+		}
+		return nil
+	}, answer)
+	return result, err
+}
+
+// Asks for a line of text. An empty answer selects `defaultValue` and the
+// answer is checked with `validate` (if not nil), asking again until a valid
+// answer is given. If an `answer` option is provided and set, its value is
+// used instead of asking.
+func (t *Terminal) Input(message, defaultValue string, validate Validator, answer ...*Option) (string, error) {
+	if defaultValue != "" {
+		message += " [" + defaultValue + "]"
+	}
+	return t.ask(message, defaultValue, validate, answer)
+}
+
+// Asks for a password without echoing the typed characters. If an `answer`
+// option is provided and set, its value is used instead of asking.
+func (t *Terminal) Password(message string, answer ...*Option) (string, error) {
+	if value, ok := optionAnswer(answer); ok {
+		return value, nil
+	}
+	fd, ok := t.inputTerminal()
+	if !ok {
+		return t.pipedAnswer(message, answer)
+	}
+	state, err := disableEcho(fd)
+	if err != nil {
+		return "", err
+	}
+	defer restoreTerminal(fd, state)
+	t.Print(message + " ")
+	line, err := t.inputReader().ReadString('\n')
+	t.Nl()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Asks the user to pick one of `choices` with the arrow keys (or j/k) and
+// enter, returning the index of the choice. When the input isn't a terminal
+// the choice is read as a line, where an empty answer selects `defaultIndex`.
+// If an `answer` option is provided and set, it selects the choice by name or
+// 1-based number instead. `defaultIndex` must be the index of a choice.
+func (t *Terminal) Select(message string, choices []string, defaultIndex int, answer ...*Option) (int, error) {
+	if len(choices) == 0 {
+		return 0, fmt.Errorf("%s: no choices", message)
+	}
+	if defaultIndex < 0 || defaultIndex >= len(choices) {
+		return 0, fmt.Errorf("%s: default choice %d out of range", message, defaultIndex)
+	}
+	if value, ok := optionAnswer(answer); ok {
+		return choiceIndex(choices, value)
+	}
+	fd, ok := t.inputTerminal()
+	if !ok {
+		value, err := t.pipedAnswer(message+" ("+strings.Join(choices, ", ")+")", answer)
+		if err != nil {
+			return 0, err
+		}
+		if strings.TrimSpace(value) == "" {
+			return defaultIndex, nil
+		}
+		return choiceIndex(choices, value)
+	}
+	menu := &menu{terminal: t, message: message, choices: choices, cursor: defaultIndex}
+	if err := menu.run(fd); err != nil {
+		return 0, err
+	}
+	return menu.cursor, nil
+}
+
+// Asks the user to pick any number of `choices` with the arrow keys, space
+// to toggle a choice, `a` to toggle all and enter to accept. Returns the
+// indexes of the chosen items in order. When the input isn't a terminal the
+// choices are read as a line, where an empty answer keeps `selected`. If an
+// `answer` option is provided and set, it selects the choices from a comma
+// separated list of names or 1-based numbers instead. `selected` must only
+// hold indexes of choices.
+func (t *Terminal) MultiSelect(message string, choices []string, selected []int, answer ...*Option) ([]int, error) {
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("%s: no choices", message)
+	}
+	for _, index := range selected {
+		if index < 0 || index >= len(choices) {
+			return nil, fmt.Errorf("%s: selected choice %d out of range", message, index)
+		}
+	}
+	value, ok := optionAnswer(answer)
+	fd, interactive := t.inputTerminal()
+	if !ok && !interactive {
+		var err error
+		value, err = t.pipedAnswer(message+" ("+strings.Join(choices, ", ")+")", answer)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(value) == "" {
+			indexes := append([]int(nil), selected...)
+			sort.Ints(indexes)
+			return indexes, nil
+		}
+		ok = true
+	}
+	if ok {
+		var indexes []int
+		for _, name := range strings.Split(value, ",") {
+			if strings.TrimSpace(name) == "" {
+				continue
+			}
+			index, err := choiceIndex(choices, name)
+			if err != nil {
+				return nil, err
+			}
+			indexes = append(indexes, index)
+		}
+		return indexes, nil
+	}
+
+	menu := &menu{terminal: t, message: message, choices: choices, multi: true, marked: map[int]bool{}}
+	for _, index := range selected {
+		menu.marked[index] = true
+	}
+	if err := menu.run(fd); err != nil {
+		return nil, err
+	}
+	var indexes []int
+	for i := range choices {
+		if menu.marked[i] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes, nil
+}
+
+// Asks a line based question, validating the answer. The answer comes from
+// the option (if set), the line editor when interactive, or piped input.
+func (t *Terminal) ask(message, defaultValue string, validate Validator, answer []*Option) (string, error) {
+	if value, ok := optionAnswer(answer); ok {
+		if validate != nil {
+			if err := validate(value); err != nil {
+				return "", fmt.Errorf("%s: %v", optionFlag(answer), err)
+			}
+		}
+		return value, nil
+	}
+	if _, ok := t.inputTerminal(); !ok {
+		value, err := t.pipedAnswer(message, answer)
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			value = defaultValue
+		}
+		if validate != nil {
+			if err := validate(value); err != nil {
+				return "", fmt.Errorf("%s: %v", message, err)
+			}
+		}
+		return value, nil
+	}
+	for {
+		value, err := t.ReadLine(message + " ")
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			value = defaultValue
+		}
+		if validate == nil {
+			return value, nil
+		}
+		err = validate(value)
+		if err == nil {
+			return value, nil
+		}
+		fmt.Fprintf(t.Program.stderr(), "  %v\n", err)
+	}
+}
+
+// Reads an answer from non-interactive input, failing clearly when there is
+// none.
+func (t *Terminal) pipedAnswer(message string, answer []*Option) (string, error) {
+	t.Print(message + " ")
+	line, err := t.inputReader().ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	t.Nl()
+	if err == io.EOF {
+		if flag := optionFlag(answer); flag != "" {
+			return "", fmt.Errorf("%s: %w (use %s or run interactively)", message, ErrNoAnswer, flag)
+		}
+		return "", fmt.Errorf("%s: %w (run interactively)", message, ErrNoAnswer)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Returns the value of the first answer option that has been set.
+func optionAnswer(answer []*Option) (string, bool) {
+	for _, option := range answer {
+		if option != nil && option.Value != "" {
+			return option.Value, true
+		}
+	}
+	return "", false
+}
+
+// Returns the flag used to pass answers non-interactively (if any).
+func optionFlag(answer []*Option) string {
+	for _, option := range answer {
+		if option == nil {
+			continue
+		}
+		if option.Long != "" {
+			return option.Long
+		}
+		return option.Short
+	}
+	return ""
+}
+
+// Finds a choice by name (case insensitive) or 1-based number.
+func choiceIndex(choices []string, value string) (int, error) {
+	value = strings.TrimSpace(value)
+	for i, choice := range choices {
+		if strings.EqualFold(choice, value) {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(value); err == nil && n > 0 && n <= len(choices) {
+		return n - 1, nil
+	}
+	return 0, fmt.Errorf("invalid choice `%s`, expected one of: %s", value, strings.Join(choices, ", "))
+}
+
+// -------------------------------------------
+// Menus
+// -------------------------------------------
+
+// menu is an interactive single or multiple choice list.
+type menu struct {
+	terminal *Terminal
+	message  string
+	choices  []string
+	cursor   int
+	multi    bool
+	marked   map[int]bool
+}
+
+// run displays the menu and handles keys until a choice is made.
+func (m *menu) run(fd int) error {
+	state, err := makeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer restoreTerminal(fd, state)
+
+	t := m.terminal
+	if m.cursor < 0 || m.cursor >= len(m.choices) {
+		m.cursor = 0
+	}
+	t.Hide()
+	defer t.Show()
+	t.Print(m.message).Nl()
+	m.render(true)
+
+	in := t.inputReader()
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return err
+		}
+		switch r {
+		case keyEnter, keyCtrlJ:
+			m.finish()
+			return nil
+		case keyCtrlC:
+			return ErrInterrupt
+		case 'k', keyCtrlP:
+			m.move(-1)
+		case 'j', keyCtrlN:
+			m.move(1)
+		case ' ':
+			if m.multi && m.marked[m.cursor] {
+				delete(m.marked, m.cursor)
+			} else if m.multi {
+				m.marked[m.cursor] = true
+			}
+		case 'a':
+			if m.multi {
+				all := len(m.marked) < len(m.choices)
+				for i := range m.choices {
+					if all {
+						m.marked[i] = true
+					} else {
+						delete(m.marked, i)
+					}
+				}
+			}
+		case keyEscape:
+			if prefix, _, _ := in.ReadRune(); prefix == '[' || prefix == 'O' {
+				switch key, _, _ := in.ReadRune(); key {
+				case 'A':
+					m.move(-1)
+				case 'B':
+					m.move(1)
+				}
+			}
+		}
+		m.render(false)
+	}
+}
+
+// move moves the menu cursor, wrapping around the list.
+func (m *menu) move(n int) {
+	m.cursor = (m.cursor + n + len(m.choices)) % len(m.choices)
+}
+
+// render draws the choices, redrawing over the previous rendering.
+func (m *menu) render(first bool) {
+	t := m.terminal
+	if !first {
+		t.Up(len(m.choices))
+	}
+	for i, choice := range m.choices {
+		t.Print("\r").ClearLine()
+		pointer := "  "
+		if i == m.cursor {
+			pointer = "> "
+		}
+		box := ""
+		if m.multi {
+			box = "[ ] "
+			if m.marked[i] {
+				box = "[x] "
+			}
+		}
+		t.Print(pointer + box + choice).Nl()
+	}
+}
+
+// finish replaces the menu with the message and the chosen answer.
+func (m *menu) finish() {
+	t := m.terminal
+	t.Up(len(m.choices) + 1)
+	var chosen []string
+	for i, choice := range m.choices {
+		if (m.multi && m.marked[i]) || (!m.multi && i == m.cursor) {
+			chosen = append(chosen, choice)
+		}
+	}
+	t.Print("\r").ClearLine().Print(m.message + " " + strings.Join(chosen, ", ")).Nl()
+	for range m.choices {
+		t.Print("\r").ClearLine().Nl()
+	}
+	t.Up(len(m.choices))
+}
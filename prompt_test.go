@@ -0,0 +1,98 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prompts", func() {
+
+	var program *Program
+	var terminal *Terminal
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		out.Reset()
+		program = New()
+		program.Out, program.Err = &out, &out
+		program.Option("-y, --yes", "answer yes to all questions")
+		program.Option("-n, --name <name>", "name to use")
+		program.Option("-c, --color <color>", "color to use")
+		terminal = program.Terminal
+	})
+
+	Context("with piped input", func() {
+		It("should confirm with defaults and answers", func() {
+			program.In = strings.NewReader("\nyes\nNo\n")
+			Ω(terminal.Confirm("Continue?", true)).Should(BeTrue())
+			Ω(terminal.Confirm("Continue?", false)).Should(BeTrue())
+			Ω(terminal.Confirm("Continue?", true)).Should(BeFalse())
+			Ω(out.String()).Should(HavePrefix("Continue? [Y/n] \n"))
+		})
+		It("should read and validate input", func() {
+			program.In = strings.NewReader("\nbad name\n")
+			Ω(terminal.Input("Name?", "gopher", nil)).Should(Equal("gopher"))
+			_, err := terminal.Input("Name?", "", func(answer string) error {
+				if strings.Contains(answer, " ") {
+					return errors.New("no spaces allowed")
+				}
+				return nil
+			})
+			Ω(err).Should(MatchError("Name?: no spaces allowed"))
+		})
+		It("should read passwords", func() {
+			program.In = strings.NewReader("secret\n")
+			Ω(terminal.Password("Password:")).Should(Equal("secret"))
+		})
+		It("should select choices by name or number", func() {
+			program.In = strings.NewReader("blue\n2\n")
+			choices := []string{"red", "green", "blue"}
+			Ω(terminal.Select("Color?", choices, 0)).Should(Equal(2))
+			Ω(terminal.Select("Color?", choices, 0)).Should(Equal(1))
+		})
+		It("should select the default choice for an empty answer", func() {
+			program.In = strings.NewReader("\n\n")
+			Ω(terminal.Select("Color?", []string{"red", "green", "blue"}, 1)).Should(Equal(1))
+			Ω(terminal.MultiSelect("Colors?", []string{"red", "green", "blue"}, []int{2, 0})).Should(Equal([]int{0, 2}))
+		})
+		It("should reject defaults that aren't choices", func() {
+			program.In = strings.NewReader("\n\n")
+			_, err := terminal.Select("Color?", []string{"red", "green"}, 2)
+			Ω(err).Should(MatchError("Color?: default choice 2 out of range"))
+			_, err = terminal.MultiSelect("Colors?", []string{"red", "green"}, []int{-1})
+			Ω(err).Should(MatchError("Colors?: selected choice -1 out of range"))
+		})
+		It("should multi select choices", func() {
+			program.In = strings.NewReader("red, 3\n")
+			Ω(terminal.MultiSelect("Colors?", []string{"red", "green", "blue"}, nil)).Should(Equal([]int{0, 2}))
+		})
+		It("should fail clearly without an answer", func() {
+			program.In = strings.NewReader("")
+			_, err := terminal.Input("Name?", "", nil, program.OptionFor("--name"))
+			Ω(errors.Is(err, ErrNoAnswer)).Should(BeTrue())
+			Ω(err.Error()).Should(ContainSubstring("use --name"))
+		})
+	})
+
+	Context("with answers from options", func() {
+		It("should use option values without reading input", func() {
+			program.In = strings.NewReader("")
+			program.ParseOptions([]string{"--yes", "--name", "gopher", "--color", "Green"})
+			Ω(terminal.Confirm("Continue?", false, program.OptionFor("--yes"))).Should(BeTrue())
+			Ω(terminal.Input("Name?", "", nil, program.OptionFor("--name"))).Should(Equal("gopher"))
+			Ω(terminal.Select("Color?", []string{"red", "green"}, 0, program.OptionFor("--color"))).Should(Equal(1))
+			Ω(out.String()).Should(BeEmpty())
+		})
+		It("should reject invalid choices", func() {
+			program.ParseOptions([]string{"--color", "pink"})
+			_, err := terminal.Select("Color?", []string{"red", "green"}, 0, program.OptionFor("--color"))
+			Ω(err).Should(MatchError("invalid choice `pink`, expected one of: red, green"))
+		})
+	})
+})
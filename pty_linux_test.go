@@ -0,0 +1,116 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+
+//go:build linux
+// +build linux
+
+package cli_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// openPTY opens a pseudo-terminal sized `cols` x `rows`, returning the
+// master side and the terminal. Skips the test without pseudo-terminals.
+func openPTY(cols, rows int) (master, tty *os.File) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		Skip("no pseudo-terminals: " + err.Error())
+	}
+	var unlock, n int32
+	ioctl := func(fd uintptr, request uintptr, arg unsafe.Pointer) {
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg))
+		Ω(errno).Should(BeZero())
+	}
+	ioctl(master.Fd(), syscall.TIOCSPTLCK, unsafe.Pointer(&unlock))
+	ioctl(master.Fd(), syscall.TIOCGPTN, unsafe.Pointer(&n))
+	tty, err = os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)), os.O_RDWR, 0)
+	Ω(err).ShouldNot(HaveOccurred())
+	size := [4]uint16{uint16(rows), uint16(cols), 0, 0}
+	ioctl(tty.Fd(), syscall.TIOCSWINSZ, unsafe.Pointer(&size))
+	return master, tty
+}
+
+var _ = Describe("Terminal output", func() {
+
+	It("should keep cursor control when color is turned off", func() {
+		master, tty := openPTY(80, 24)
+		defer master.Close()
+		defer tty.Close()
+		os.Setenv("NO_COLOR", "1")
+		defer os.Unsetenv("NO_COLOR")
+		program := New()
+		program.Out = tty
+		terminal := program.Terminal
+
+		Ω(terminal.ColorEnabled()).Should(BeFalse())
+		Ω(terminal.CursorEnabled()).Should(BeTrue())
+		terminal.Up(3).ClearLine().Style(Bold).Hide().Print("x")
+		buffer := make([]byte, 64)
+		n, err := master.Read(buffer)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(buffer[:n])).Should(Equal("\033[3A\033[2K\033[?25lx"))
+	})
+	It("should return signals from ReadEvent and exit from the screen goroutine", func() {
+		master, tty := openPTY(80, 24)
+		defer master.Close()
+		defer tty.Close()
+		go io.Copy(ioutil.Discard, master)
+		program := New()
+		program.Out = tty
+		program.In = tty
+		code := 0
+		program.Exit = func(c int) { code = c }
+
+		err := program.Terminal.RunScreen(func(s *Screen) error {
+			Ω(syscall.Kill(os.Getpid(), syscall.SIGHUP)).Should(Succeed())
+			event, err := s.ReadEvent()
+			Ω(event).Should(BeNil())
+			return err
+		})
+		Ω(err).Should(MatchError("interrupted by hangup"))
+		Ω(code).Should(Equal(129))
+	})
+	It("should write the frame before asking for the cursor position", func() {
+		master, tty := openPTY(80, 24)
+		defer master.Close()
+		defer tty.Close()
+		output := make(chan string, 1)
+		go func() {
+			var seen []byte
+			buffer := make([]byte, 64)
+			for !strings.Contains(string(seen), "\033[6n") {
+				n, err := master.Read(buffer)
+				if err != nil {
+					break
+				}
+				seen = append(seen, buffer[:n]...)
+			}
+			master.Write([]byte("\033[5;10R"))
+			output <- string(seen)
+		}()
+		program := New()
+		program.Out = tty
+		program.In = tty
+		terminal := program.Terminal
+
+		var x, y int
+		var err error
+		Ω(terminal.Frame(func() {
+			terminal.Print("hello")
+			x, y, err = terminal.CursorPosition()
+		})).Should(Succeed())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω([]int{x, y}).Should(Equal([]int{10, 5}))
+		Ω(<-output).Should(Equal("hello\033[6n"))
+	})
+})
@@ -0,0 +1,298 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupt is returned by ReadLine when the user presses Ctrl-C.
+var ErrInterrupt = errors.New("interrupted")
+
+// Completer returns the completion candidates for the last word of `line`.
+type Completer func(line string) []string
+
+// Key codes understood by the line editor.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyCtrlJ     = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// ReadLine displays `prompt` and reads a line of input. When the program
+// input is a terminal the line can be edited: arrow keys, Ctrl-A/E (start and
+// end of line), Ctrl-K/U (delete to end or start), Ctrl-W (delete word),
+// Ctrl-P/N or up/down (history) and tab (completion with the terminal
+// Completer). Otherwise the line is read as plain buffered input. Returns
+// io.EOF at end of input and ErrInterrupt on Ctrl-C. The line is not added
+// to the history; see AddHistory.
+func (t *Terminal) ReadLine(prompt string) (string, error) {
+	if fd, ok := t.inputTerminal(); ok {
+		state, err := makeRaw(fd)
+		if err == nil {
+			defer restoreTerminal(fd, state)
+			editor := &lineEditor{terminal: t, out: t.Program.stdout(), prompt: prompt}
+			return editor.edit(t.inputReader())
+		}
+	}
+
+	fmt.Fprint(t.Program.stdout(), prompt)
+	line, err := t.inputReader().ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Sets the completion function used by ReadLine
+func (t *Terminal) SetCompleter(completer Completer) *Terminal {
+	t.Completer = completer
+	return t
+}
+
+// Returns the file descriptor of the program input if it is a terminal
+func (t *Terminal) inputTerminal() (int, bool) {
+	file, ok := t.Program.stdin().(*os.File)
+	if !ok || !isTerminal(int(file.Fd())) {
+		return 0, false
+	}
+	return int(file.Fd()), true
+}
+
+// Returns a buffered reader for the program input, kept between reads so
+// buffered input isn't lost.
+func (t *Terminal) inputReader() *bufio.Reader {
+	in := t.Program.stdin()
+	if t.reader == nil || t.readerSource != in {
+		t.reader = bufio.NewReader(in)
+		t.readerSource = in
+	}
+	return t.reader
+}
+
+// lineEditor holds the state of a line being edited in raw mode.
+type lineEditor struct {
+	terminal *Terminal
+	out      io.Writer
+	prompt   string
+	line     []rune
+	pos      int    // Cursor position in line
+	history  int    // Index of the history entry displayed
+	pending  []rune // Line being edited before browsing history
+}
+
+// edit reads keys from `in` until the line is entered.
+func (e *lineEditor) edit(in *bufio.Reader) (string, error) {
+	e.history = len(e.terminal.History)
+	e.refresh()
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case keyEnter, keyCtrlJ:
+			fmt.Fprint(e.out, "\r\n")
+			return string(e.line), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupt
+		case keyCtrlD:
+			if len(e.line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.delete(e.pos, e.pos+1)
+		case keyCtrlA:
+			e.pos = 0
+		case keyCtrlE:
+			e.pos = len(e.line)
+		case keyCtrlB:
+			e.move(-1)
+		case keyCtrlF:
+			e.move(1)
+		case keyCtrlK:
+			e.delete(e.pos, len(e.line))
+		case keyCtrlU:
+			e.delete(0, e.pos)
+		case keyCtrlW:
+			e.delete(e.wordStart(), e.pos)
+		case keyBackspace, keyCtrlH:
+			if e.pos > 0 {
+				e.delete(e.pos-1, e.pos)
+			}
+		case keyCtrlP:
+			e.browse(-1)
+		case keyCtrlN:
+			e.browse(1)
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\033[H\033[2J")
+		case keyTab:
+			e.complete()
+		case keyEscape:
+			e.escape(in)
+		default:
+			if unicode.IsPrint(r) {
+				e.insert([]rune{r})
+			}
+		}
+		e.refresh()
+	}
+}
+
+// escape decodes the escape sequences sent by cursor and editing keys.
+func (e *lineEditor) escape(in *bufio.Reader) {
+	prefix, _, err := in.ReadRune()
+	if err != nil || (prefix != '[' && prefix != 'O') {
+		return
+	}
+	var params []rune
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return
+		}
+		if r < '0' || r > '9' && r != ';' {
+			switch {
+			case r == 'A':
+				e.browse(-1)
+			case r == 'B':
+				e.browse(1)
+			case r == 'C':
+				e.move(1)
+			case r == 'D':
+				e.move(-1)
+			case r == 'H' || (r == '~' && (string(params) == "1" || string(params) == "7")):
+				e.pos = 0
+			case r == 'F' || (r == '~' && (string(params) == "4" || string(params) == "8")):
+				e.pos = len(e.line)
+			case r == '~' && string(params) == "3":
+				e.delete(e.pos, e.pos+1)
+			}
+			return
+		}
+		params = append(params, r)
+	}
+}
+
+// refresh redraws the prompt and line and positions the cursor.
+func (e *lineEditor) refresh() {
+	fmt.Fprintf(e.out, "\r%s%s\033[K", e.prompt, string(e.line))
+	if back := len(e.line) - e.pos; back > 0 {
+		fmt.Fprintf(e.out, "\033[%dD", back)
+	}
+}
+
+// move moves the cursor `n` characters, staying within the line.
+func (e *lineEditor) move(n int) {
+	e.pos += n
+	if e.pos < 0 {
+		e.pos = 0
+	}
+	if e.pos > len(e.line) {
+		e.pos = len(e.line)
+	}
+}
+
+// insert inserts text at the cursor.
+func (e *lineEditor) insert(text []rune) {
+	line := append([]rune{}, e.line[:e.pos]...)
+	line = append(line, text...)
+	e.line = append(line, e.line[e.pos:]...)
+	e.pos += len(text)
+}
+
+// delete removes the characters from `start` up to `end`.
+func (e *lineEditor) delete(start, end int) {
+	if end > len(e.line) {
+		end = len(e.line)
+	}
+	if start >= end {
+		return
+	}
+	e.line = append(e.line[:start], e.line[end:]...)
+	e.pos = start
+}
+
+// wordStart returns the start of the word before the cursor.
+func (e *lineEditor) wordStart() int {
+	i := e.pos
+	for i > 0 && unicode.IsSpace(e.line[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(e.line[i-1]) {
+		i--
+	}
+	return i
+}
+
+// browse replaces the line with the history entry `n` steps away.
+func (e *lineEditor) browse(n int) {
+	history := e.terminal.History
+	next := e.history + n
+	if next < 0 || next > len(history) {
+		return
+	}
+	if e.history == len(history) {
+		e.pending = e.line
+	}
+	e.history = next
+	if next == len(history) {
+		e.line = e.pending
+	} else {
+		e.line = []rune(history[next])
+	}
+	e.pos = len(e.line)
+}
+
+// complete completes the word before the cursor. A single candidate replaces
+// the word, several candidates are extended to their common prefix or listed.
+func (e *lineEditor) complete() {
+	if e.terminal.Completer == nil {
+		return
+	}
+	candidates := e.terminal.Completer(string(e.line[:e.pos]))
+	if len(candidates) == 0 {
+		return
+	}
+	start := e.wordStart()
+	if e.pos > 0 && unicode.IsSpace(e.line[e.pos-1]) {
+		start = e.pos
+	}
+	word := string(e.line[start:e.pos])
+	// Shorten by runes so a common prefix never ends mid-character
+	completion := []rune(candidates[0])
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, string(completion)) {
+			completion = completion[:len(completion)-1]
+		}
+	}
+	if len(candidates) == 1 {
+		completion = append(completion, ' ')
+	} else if string(completion) == word {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+		return
+	}
+	e.delete(start, e.pos)
+	e.insert(completion)
+}
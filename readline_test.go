@@ -0,0 +1,88 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Line editing", func() {
+
+	var terminal *Terminal
+
+	edit := func(keys string) (string, error) {
+		editor := &lineEditor{terminal: terminal, out: &bytes.Buffer{}, prompt: "> "}
+		return editor.edit(bufio.NewReader(strings.NewReader(keys)))
+	}
+
+	BeforeEach(func() {
+		terminal = New().Terminal
+		terminal.History = []string{"first", "second"}
+	})
+
+	It("should insert text and move the cursor", func() {
+		Ω(edit("wrld\033[D\033[D\033[Do\x01hello \x05!\r")).Should(Equal("hello world!"))
+	})
+	It("should kill to the end and start of the line", func() {
+		Ω(edit("hello world\x01\x06\x06\x06\x06\x06\x0b\r")).Should(Equal("hello"))
+		Ω(edit("hello world\x02\x02\x15\r")).Should(Equal("ld"))
+	})
+	It("should delete the previous word", func() {
+		Ω(edit("say hello  \x17\r")).Should(Equal("say "))
+	})
+	It("should delete characters", func() {
+		Ω(edit("abc\x7f\x01\033[3~\r")).Should(Equal("b"))
+	})
+	It("should navigate history", func() {
+		Ω(edit("\033[A\r")).Should(Equal("second"))
+		Ω(edit("\x10\x10\x10\r")).Should(Equal("first"))
+		Ω(edit("new\033[A\033[B\r")).Should(Equal("new"))
+	})
+	It("should complete words", func() {
+		terminal.SetCompleter(func(line string) []string {
+			switch line {
+			case "he":
+				return []string{"help"}
+			case "help t":
+				return []string{"tcp", "topic"}
+			}
+			return nil
+		})
+		Ω(edit("he\t\r")).Should(Equal("help "))
+		Ω(edit("help t\to\r")).Should(Equal("help to"))
+	})
+	It("should complete words after multibyte characters", func() {
+		terminal.SetCompleter(func(line string) []string {
+			switch line {
+			case "café r":
+				return []string{"résumé"}
+			case "café ":
+				return []string{"été", "ètre"}
+			}
+			return nil
+		})
+		Ω(edit("café r\t\r")).Should(Equal("café résumé "))
+		Ω(edit("café \tt\r")).Should(Equal("café t"))
+	})
+	It("should report end of input and interrupts", func() {
+		_, err := edit("\x04")
+		Ω(err).Should(Equal(io.EOF))
+		_, err = edit("abc\x03")
+		Ω(err).Should(Equal(ErrInterrupt))
+	})
+	It("should fall back to buffered reading without a terminal", func() {
+		var out bytes.Buffer
+		terminal.Program.Out = &out
+		terminal.Program.In = strings.NewReader("one\ntwo")
+		Ω(terminal.ReadLine("? ")).Should(Equal("one"))
+		Ω(terminal.ReadLine("? ")).Should(Equal("two"))
+		_, err := terminal.ReadLine("? ")
+		Ω(err).Should(Equal(io.EOF))
+		Ω(out.String()).Should(Equal("? ? ? "))
+	})
+})
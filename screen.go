@@ -0,0 +1,427 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ErrNotInteractive is returned by Terminal.Screen when the program input
+// isn't a terminal.
+var ErrNotInteractive = errors.New("input is not a terminal")
+
+// SignalError is returned by Screen.ReadEvent when the program receives an
+// interrupt or termination signal (SIGINT, SIGTERM, SIGHUP) during a
+// full-screen session. Its exit code is 128 plus the signal number.
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (e *SignalError) Error() string {
+	return "interrupted by " + e.Signal.String()
+}
+
+// ExitCode returns the conventional exit code for the signal.
+func (e *SignalError) ExitCode() int {
+	return signalExitCode(e.Signal)
+}
+
+// signalPoll is how often ReadEvent checks for signals while waiting for
+// input.
+const signalPoll = 50 * time.Millisecond
+
+// Key identifies a key in a KeyEvent.
+type Key int
+
+// Keys decoded by Screen.ReadEvent. KeyRune is a character key (see
+// KeyEvent.Rune) and KeyUnknown an unrecognised escape sequence (see
+// KeyEvent.Sequence).
+const (
+	KeyRune Key = iota
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyEscape
+	KeyUp
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyInsert
+	KeyDelete
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeyUnknown
+)
+
+var keyNames = map[Key]string{
+	KeyEnter: "Enter", KeyTab: "Tab", KeyBackspace: "Backspace", KeyEscape: "Esc",
+	KeyUp: "Up", KeyDown: "Down", KeyRight: "Right", KeyLeft: "Left",
+	KeyHome: "Home", KeyEnd: "End", KeyPageUp: "PgUp", KeyPageDown: "PgDn",
+	KeyInsert: "Insert", KeyDelete: "Delete", KeyUnknown: "Unknown",
+}
+
+// String returns the key name ("Up", "F5"...).
+func (k Key) String() string {
+	if k >= KeyF1 && k <= KeyF12 {
+		return "F" + strconv.Itoa(int(k-KeyF1)+1)
+	}
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	return "Rune"
+}
+
+// Event is an input event read from a Screen: a KeyEvent or PasteEvent.
+type Event interface{}
+
+// KeyEvent is a key press with its modifiers.
+type KeyEvent struct {
+	Key   Key
+	Rune  rune // Character for KeyRune (lower case letter for Ctrl combos)
+	Ctrl  bool
+	Alt   bool
+	Shift bool
+
+	Sequence string // Raw escape sequence for KeyUnknown
+}
+
+// String describes the key press ("Ctrl+C", "Shift+Up", "x").
+func (e KeyEvent) String() string {
+	var b strings.Builder
+	if e.Ctrl {
+		b.WriteString("Ctrl+")
+	}
+	if e.Alt {
+		b.WriteString("Alt+")
+	}
+	if e.Shift {
+		b.WriteString("Shift+")
+	}
+	if e.Key == KeyRune {
+		if e.Ctrl {
+			b.WriteRune(unicode.ToUpper(e.Rune))
+		} else {
+			b.WriteRune(e.Rune)
+		}
+	} else {
+		b.WriteString(e.Key.String())
+	}
+	return b.String()
+}
+
+// PasteEvent is text pasted into the terminal (bracketed paste).
+type PasteEvent struct {
+	Text string
+}
+
+// Screen is a full-screen session: the terminal shows the alternate screen
+// buffer, input is in raw mode and keys are read as events. Close restores
+// the terminal; it is also restored when the program exits through
+// Program.Exit, and RunScreen restores it after a panic. Signals that would
+// end the program (SIGINT, SIGTERM, SIGHUP) are caught while the session is
+// open and returned by ReadEvent as a SignalError; RunScreen then restores
+// the terminal and exits with the signal's exit code.
+type Screen struct {
+	Terminal *Terminal
+
+	fd      int
+	state   *ttyState
+	in      *bufio.Reader
+	signals chan os.Signal
+	once    sync.Once
+	err     error
+}
+
+// Starts a full-screen session. Fails with ErrNotInteractive when the
+// program input isn't a terminal.
+func (t *Terminal) Screen() (*Screen, error) {
+	fd, ok := t.inputTerminal()
+	if !ok {
+		return nil, ErrNotInteractive
+	}
+	state, err := makeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	s := &Screen{
+		Terminal: t,
+		fd:       fd,
+		state:    state,
+		in:       t.inputReader(),
+		signals:  make(chan os.Signal, 1),
+	}
+	t.mu.Lock()
+	t.screen = s
+	t.mu.Unlock()
+	// Alternate screen buffer, bracketed paste, then clear and home
+	t.Print("\033[?1049h\033[?2004h\033[2J\033[H")
+	// Report signals through ReadEvent so the terminal is restored
+	signal.Notify(s.signals, screenSignals...)
+	return s, nil
+}
+
+// Runs `view` in a full-screen session, restoring the terminal when it
+// returns or panics. When the view returns a SignalError (from ReadEvent)
+// the program exits with its exit code.
+func (t *Terminal) RunScreen(view func(s *Screen) error) error {
+	s, err := t.Screen()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	err = view(s)
+	var interrupted *SignalError
+	if errors.As(err, &interrupted) {
+		s.Close()
+		t.Program.exit(interrupted.ExitCode())
+	}
+	return err
+}
+
+// Close leaves the session, returning to the normal screen with the cursor
+// visible and the previous terminal settings.
+func (s *Screen) Close() error {
+	s.once.Do(func() {
+		signal.Stop(s.signals)
+		t := s.Terminal
+		t.mu.Lock()
+		if t.screen == s {
+			t.screen = nil
+		}
+		t.mu.Unlock()
+		t.Print("\033[?2004l\033[?1049l\033[?25h")
+		s.err = restoreTerminal(s.fd, s.state)
+	})
+	return s.err
+}
+
+// ReadEvent waits for the next key press or paste. It returns a SignalError
+// when the program is signalled while waiting.
+func (s *Screen) ReadEvent() (Event, error) {
+	for s.in.Buffered() == 0 {
+		select {
+		case sig := <-s.signals:
+			return nil, &SignalError{Signal: sig}
+		default:
+		}
+		if waitInput(s.fd, signalPoll) {
+			break
+		}
+	}
+	return readEvent(s.in)
+}
+
+// readEvent decodes the next event from raw terminal input.
+func readEvent(in *bufio.Reader) (Event, error) {
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case r == keyEnter:
+		return KeyEvent{Key: KeyEnter}, nil
+	case r == keyTab:
+		return KeyEvent{Key: KeyTab}, nil
+	case r == keyBackspace || r == keyCtrlH:
+		return KeyEvent{Key: KeyBackspace}, nil
+	case r == keyEscape:
+		return readEscape(in)
+	case r == 0:
+		return KeyEvent{Key: KeyRune, Rune: ' ', Ctrl: true}, nil
+	case r <= 26:
+		return KeyEvent{Key: KeyRune, Rune: 'a' + r - 1, Ctrl: true}, nil
+	case r < ' ':
+		return KeyEvent{Key: KeyRune, Rune: r + '@', Ctrl: true}, nil
+	}
+	return KeyEvent{Key: KeyRune, Rune: r}, nil
+}
+
+// readEscape decodes the input following an escape: a lone Esc, an Alt
+// combination or a CSI/SS3 sequence.
+func readEscape(in *bufio.Reader) (Event, error) {
+	if in.Buffered() == 0 {
+		return KeyEvent{Key: KeyEscape}, nil
+	}
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return nil, err
+	}
+	if r != '[' && r != 'O' {
+		in.UnreadRune()
+		event, err := readEvent(in)
+		if key, ok := event.(KeyEvent); ok {
+			key.Alt = true
+			return key, err
+		}
+		return event, err
+	}
+	ss3 := r == 'O'
+
+	var params []byte
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b >= 0x40 && b <= 0x7e {
+			return decodeSequence(in, ss3, string(params), b)
+		}
+		params = append(params, b)
+	}
+}
+
+// decodeSequence converts a CSI or SS3 sequence (parameters and final byte)
+// into an event.
+func decodeSequence(in *bufio.Reader, ss3 bool, params string, final byte) (Event, error) {
+	fields := strings.Split(params, ";")
+	event := KeyEvent{}
+	if len(fields) > 1 {
+		// Modifier parameter: 1 + shift(1) + alt(2) + ctrl(4)
+		if mod, err := strconv.Atoi(fields[1]); err == nil && mod > 1 {
+			event.Shift = (mod-1)&1 != 0
+			event.Alt = (mod-1)&2 != 0
+			event.Ctrl = (mod-1)&4 != 0
+		}
+	}
+	switch final {
+	case 'A':
+		event.Key = KeyUp
+	case 'B':
+		event.Key = KeyDown
+	case 'C':
+		event.Key = KeyRight
+	case 'D':
+		event.Key = KeyLeft
+	case 'H':
+		event.Key = KeyHome
+	case 'F':
+		event.Key = KeyEnd
+	case 'Z':
+		event.Key, event.Shift = KeyTab, true
+	case 'P', 'Q', 'R', 'S':
+		event.Key = KeyF1 + Key(final-'P')
+	case '~':
+		code, _ := strconv.Atoi(fields[0])
+		if code == 200 {
+			return readPaste(in)
+		}
+		key, ok := tildeKeys[code]
+		if !ok {
+			return unknownSequence(event, ss3, params, final), nil
+		}
+		event.Key = key
+	default:
+		return unknownSequence(event, ss3, params, final), nil
+	}
+	return event, nil
+}
+
+// tildeKeys maps the codes of `ESC [ <code> ~` sequences to keys.
+var tildeKeys = map[int]Key{
+	1: KeyHome, 2: KeyInsert, 3: KeyDelete, 4: KeyEnd, 5: KeyPageUp, 6: KeyPageDown,
+	7: KeyHome, 8: KeyEnd,
+	11: KeyF1, 12: KeyF2, 13: KeyF3, 14: KeyF4, 15: KeyF5,
+	17: KeyF6, 18: KeyF7, 19: KeyF8, 20: KeyF9, 21: KeyF10, 23: KeyF11, 24: KeyF12,
+}
+
+// unknownSequence marks `event` as KeyUnknown for an unrecognised sequence.
+func unknownSequence(event KeyEvent, ss3 bool, params string, final byte) KeyEvent {
+	introducer := "\033["
+	if ss3 {
+		introducer = "\033O"
+	}
+	event.Key, event.Sequence = KeyUnknown, introducer+params+string(final)
+	return event
+}
+
+// readPaste reads bracketed paste text up to the `ESC [ 201 ~` terminator.
+func readPaste(in *bufio.Reader) (Event, error) {
+	const end = "\033[201~"
+	var text strings.Builder
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		text.WriteByte(b)
+		if strings.HasSuffix(text.String(), end) {
+			return PasteEvent{Text: strings.TrimSuffix(text.String(), end)}, nil
+		}
+	}
+}
+
+// ErrNoCursorReport is returned by Terminal.CursorPosition when the terminal
+// doesn't answer the position request in time.
+var ErrNoCursorReport = errors.New("terminal did not report the cursor position")
+
+// readCursorReport parses the `ESC [ row ; col R` reply to a cursor position
+// request. Input read before the reply is returned in `pending`. `wait` is
+// called before reading unbuffered input and returns false when no more
+// input arrives in time (nil waits forever).
+func readCursorReport(in *bufio.Reader, wait func() bool) (x, y int, pending []byte, err error) {
+	next := func() (byte, error) {
+		if in.Buffered() == 0 && wait != nil && !wait() {
+			return 0, ErrNoCursorReport
+		}
+		return in.ReadByte()
+	}
+	for {
+		b, err := next()
+		if err != nil {
+			return 0, 0, pending, err
+		}
+		if b != '\033' {
+			pending = append(pending, b)
+			continue
+		}
+		sequence := []byte{b}
+		for err == nil {
+			if b, err = next(); err == nil {
+				sequence = append(sequence, b)
+			}
+			if len(sequence) == 2 && b != '[' || len(sequence) > 2 && b != ';' && (b < '0' || b > '9') {
+				break
+			}
+		}
+		if err != nil {
+			return 0, 0, append(pending, sequence...), err
+		}
+		if len(sequence) > 2 && b == 'R' {
+			fields := strings.Split(string(sequence[2:len(sequence)-1]), ";")
+			if len(fields) == 2 {
+				row, rowErr := strconv.Atoi(fields[0])
+				col, colErr := strconv.Atoi(fields[1])
+				if rowErr == nil && colErr == nil {
+					return col, row, pending, nil
+				}
+			}
+		}
+		if b == '\033' {
+			// The start of the next sequence
+			in.UnreadByte()
+			sequence = sequence[:len(sequence)-1]
+		}
+		pending = append(pending, sequence...)
+	}
+}
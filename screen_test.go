@@ -0,0 +1,89 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Screen", func() {
+
+	events := func(input string) []string {
+		in := bufio.NewReader(strings.NewReader(input))
+		var names []string
+		for {
+			event, err := readEvent(in)
+			if err != nil {
+				return names
+			}
+			switch e := event.(type) {
+			case KeyEvent:
+				names = append(names, e.String())
+			case PasteEvent:
+				names = append(names, "paste:"+e.Text)
+			}
+		}
+	}
+
+	It("should decode characters, control keys and Ctrl combinations", func() {
+		Ω(events("aé\r\t\x7f\x03\x1a")).Should(Equal([]string{"a", "é", "Enter", "Tab", "Backspace", "Ctrl+C", "Ctrl+Z"}))
+	})
+	It("should decode cursor, editing and function keys", func() {
+		Ω(events("\033[A\033[B\033OC\033[D\033[H\033[4~\033[5~\033[3~\033OP\033[15~\033[24~")).Should(Equal([]string{
+			"Up", "Down", "Right", "Left", "Home", "End", "PgUp", "Delete", "F1", "F5", "F12",
+		}))
+	})
+	It("should decode modifiers and Alt combinations", func() {
+		Ω(events("\033[1;5A\033[1;2C\033[Z\033x\033")).Should(Equal([]string{"Ctrl+Up", "Shift+Right", "Shift+Tab", "Alt+x", "Esc"}))
+	})
+	It("should report unknown sequences as events", func() {
+		Ω(events("\033[99~\033[1;5X\033OzA")).Should(Equal([]string{"Unknown", "Ctrl+Unknown", "Unknown", "A"}))
+		event, err := readEvent(bufio.NewReader(strings.NewReader("\033[99~")))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(event).Should(Equal(KeyEvent{Key: KeyUnknown, Sequence: "\033[99~"}))
+		event, err = readEvent(bufio.NewReader(strings.NewReader("\033Oz")))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(event).Should(Equal(KeyEvent{Key: KeyUnknown, Sequence: "\033Oz"}))
+	})
+	It("should decode bracketed paste", func() {
+		Ω(events("\033[200~hello\033[Aworld\033[201~q")).Should(Equal([]string{"paste:hello\033[Aworld", "q"}))
+	})
+	It("should parse cursor position reports", func() {
+		x, y, pending, err := readCursorReport(bufio.NewReader(strings.NewReader("ab\033[A\033\033[12;40Rc")), nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(x).Should(Equal(40))
+		Ω(y).Should(Equal(12))
+		Ω(string(pending)).Should(Equal("ab\033[A\033"))
+	})
+	It("should stop waiting for cursor position reports", func() {
+		in := bufio.NewReader(strings.NewReader("ab\033[1"))
+		waits := 0
+		_, _, pending, err := readCursorReport(in, func() bool {
+			waits++
+			return waits == 1
+		})
+		Ω(err).Should(Equal(ErrNoCursorReport))
+		Ω(string(pending)).Should(Equal("ab\033[1"))
+	})
+	It("should put back input read before cursor reports", func() {
+		program := New()
+		program.In = strings.NewReader("xyz")
+		terminal := program.Terminal
+		terminal.inputReader().ReadByte()
+		terminal.unread([]byte("ab"))
+		rest, _ := ioutil.ReadAll(terminal.inputReader())
+		Ω(string(rest)).Should(Equal("abyz"))
+	})
+	It("should require an interactive terminal", func() {
+		program := New()
+		program.In = &bytes.Buffer{}
+		_, err := program.Terminal.Screen()
+		Ω(err).Should(Equal(ErrNotInteractive))
+		Ω(program.Terminal.RunScreen(func(s *Screen) error { return nil })).Should(Equal(ErrNotInteractive))
+	})
+})
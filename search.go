@@ -0,0 +1,305 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SearchResult is a command, option or topic matching a help search.
+type SearchResult struct {
+	Kind    string // "command", "option" or "topic"
+	Name    string // Command flags, option flags or topic name
+	Command string // Command owning an option ("" for program options)
+	Snippet string // Text around the first match
+	Score   int    // Relevance (higher is better)
+}
+
+// Search weights for matches in each part of a help entry.
+const (
+	searchExactName  = 100
+	searchNamePrefix = 50
+	searchName       = 30
+	searchDesc       = 10
+	searchBody       = 3
+)
+
+// searchEntry is the searchable text of a command, option or topic.
+type searchEntry struct {
+	result                  SearchResult
+	name, title, desc, body string
+}
+
+// Search finds the commands, options and topics matching every word of
+// `term` (ignoring case) in their names, descriptions or bodies, most
+// relevant first. Name matches rank above description matches, which rank
+// above body matches.
+func (p *Program) Search(term string) []SearchResult {
+	words := strings.Fields(term)
+	if len(words) == 0 {
+		return nil
+	}
+	var results []SearchResult
+	for _, entry := range p.searchEntries() {
+		score := 0
+		for _, word := range words {
+			s := entry.score(word)
+			if s == 0 {
+				score = 0
+				break
+			}
+			score += s
+		}
+		if score == 0 {
+			continue
+		}
+		result := entry.result
+		result.Score = score
+		result.Snippet = entry.snippet(words)
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+// searchEntries lists the program commands, options and topics.
+func (p *Program) searchEntries() []searchEntry {
+	var entries []searchEntry
+	var names []string
+	for name := range p.Commands {
+		if name != "*" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	option := func(command string, o *Option) searchEntry {
+		name := strings.TrimLeft(o.Long, "-")
+		if name == "" {
+			name = strings.TrimLeft(o.Short, "-")
+		}
+		return searchEntry{
+			result: SearchResult{Kind: "option", Name: o.Flags, Command: command},
+			name:   name,
+			desc:   o.Description,
+		}
+	}
+	for _, o := range p.Options {
+		entries = append(entries, option("", o))
+	}
+	for _, name := range names {
+		command := p.Commands[name]
+		entries = append(entries, searchEntry{
+			result: SearchResult{Kind: "command", Name: command.Flags},
+			name:   command.Command,
+			desc:   command.Description,
+			body:   command.Body,
+		})
+		for _, o := range command.Options {
+			entries = append(entries, option(command.Command, o))
+		}
+	}
+	names = names[:0]
+	for name := range p.Topics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		topic := p.Topics[name]
+		entries = append(entries, searchEntry{
+			result: SearchResult{Kind: "topic", Name: name},
+			name:   name,
+			title:  topic.Title,
+			desc:   topic.Description,
+			body:   topic.Body,
+		})
+	}
+	return entries
+}
+
+// score returns how well `word` matches the entry (0 if it doesn't).
+func (e searchEntry) score(word string) int {
+	score := 0
+	switch {
+	case strings.EqualFold(e.name, word):
+		score += searchExactName
+	case indexFold(e.name, word) == 0:
+		score += searchNamePrefix
+	case indexFold(e.name, word) > 0:
+		score += searchName
+	}
+	if indexFold(e.title, word) >= 0 || indexFold(e.desc, word) >= 0 {
+		score += searchDesc
+	}
+	// Bodies count once per match, up to five
+	body := e.body
+	for n := 0; n < 5; n++ {
+		_, end := matchFold(body, word)
+		if end < 0 {
+			break
+		}
+		score += searchBody
+		body = body[end:]
+	}
+	return score
+}
+
+// snippet returns the text around the first match in the description,
+// title or body of the entry, or the description.
+func (e searchEntry) snippet(words []string) string {
+	for _, text := range []string{e.desc, e.title, plainText(e.body)} {
+		for _, word := range words {
+			if start, end := matchFold(text, word); start >= 0 {
+				return excerpt(text, start, end-start, 72)
+			}
+		}
+	}
+	return e.desc
+}
+
+// plainText strips common Markdown markup and joins the lines of `text`,
+// leaving out headings so they don't run into the text that follows.
+func plainText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if mdHeading.MatchString(strings.TrimSpace(line)) {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#>*-"))
+		if line != "" && !strings.HasPrefix(line, "```") {
+			lines = append(lines, line)
+		}
+	}
+	text = strings.Join(lines, " ")
+	return strings.NewReplacer("**", "", "__", "", "`", "").Replace(text)
+}
+
+// excerpt returns about `width` characters of `text` around the match at
+// byte `start` of length `length`, with ellipses where text was cut.
+func excerpt(text string, start, length, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	// Keep a third of the width before the match
+	from := start
+	for n := 0; n < width/3 && from > 0; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:from])
+		from -= size
+	}
+	// Start at a word
+	if from > 0 {
+		if i := strings.IndexFunc(text[from:start], unicode.IsSpace); i >= 0 {
+			from += i + 1
+		}
+	}
+	to := from
+	for n := 0; n < width && to < len(text); n++ {
+		_, size := utf8.DecodeRuneInString(text[to:])
+		to += size
+	}
+	if to < start+length {
+		to = start + length
+	}
+	// End at a word
+	if to < len(text) {
+		if i := strings.LastIndexFunc(text[start+length:to], unicode.IsSpace); i >= 0 {
+			to = start + length + i
+		}
+	}
+	snippet := strings.TrimSpace(text[from:to])
+	if from > 0 {
+		snippet = "…" + snippet
+	}
+	if to < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// indexFold returns the byte index of the first case insensitive match of
+// `word` in `text`, or -1.
+func indexFold(text, word string) int {
+	start, _ := matchFold(text, word)
+	return start
+}
+
+// matchFold returns the byte offsets of the first case insensitive match of
+// `word` in `text`, or -1, -1. Runes are compared one by one since a folded
+// rune can be encoded with a different number of bytes (K and k).
+func matchFold(text, word string) (start, end int) {
+	if word == "" {
+		return 0, 0
+	}
+	for start = range text {
+		if length := prefixFold(text[start:], word); length >= 0 {
+			return start, start + length
+		}
+	}
+	return -1, -1
+}
+
+// prefixFold returns the byte length of the case insensitive match of `word`
+// at the start of `text`, or -1.
+func prefixFold(text, word string) int {
+	length := 0
+	for _, w := range word {
+		r, size := utf8.DecodeRuneInString(text[length:])
+		if size == 0 || !strings.EqualFold(string(r), string(w)) {
+			return -1
+		}
+		length += size
+	}
+	return length
+}
+
+// highlight styles every case insensitive match of `words` in `text`.
+func (t *Terminal) highlight(text string, words []string) string {
+	var b strings.Builder
+	for text != "" {
+		start, end := -1, 0
+		for _, word := range words {
+			if i, j := matchFold(text, word); i >= 0 && (start < 0 || i < start) {
+				start, end = i, j
+			}
+		}
+		if start < 0 || end == start {
+			b.WriteString(text)
+			break
+		}
+		b.WriteString(text[:start])
+		b.WriteString(t.Paint(text[start:end], Bold, Fg(Yellow)))
+		text = text[end:]
+	}
+	return b.String()
+}
+
+// printSearch writes the help search results for `term` to `out`.
+func printSearch(p *Program, out io.Writer, term string) {
+	results := p.Search(term)
+	if len(results) == 0 {
+		fmt.Fprintf(out, "No help found for %q.\n", term)
+		return
+	}
+	words := strings.Fields(term)
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		name := result.Name
+		if result.Command != "" {
+			name = result.Command + " " + name
+		}
+		fmt.Fprintln(out, p.Terminal.highlight(name, words)+p.Terminal.Paint(" ("+result.Kind+")", Dim))
+		if result.Snippet != "" {
+			fmt.Fprintln(out, "  "+p.Terminal.highlight(result.Snippet, words))
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Help search", func() {
+
+	var program *Program
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		out.Reset()
+		program = New()
+		program.Out = &out
+		program.Command("capture <iface>", "capture packets").
+			Option("-f, --filter <expr>", "capture filter expression").
+			SetBody("Captures packets on an interface. Use a **filter** to select them.")
+		program.Topic("filters", "writing capture filters").SetBody("# Filters\n\nFilters select the packets to keep.")
+		program.Command("status", "show the capture status")
+	})
+
+	It("should rank name matches above descriptions and bodies", func() {
+		var names []string
+		for _, result := range program.Search("filter") {
+			names = append(names, result.Kind+" "+result.Name)
+		}
+		Ω(names).Should(Equal([]string{"option -f, --filter <expr>", "topic filters", "command capture <iface>"}))
+	})
+
+	It("should match every word ignoring case", func() {
+		results := program.Search("STATUS capture")
+		Ω(results).Should(HaveLen(1))
+		Ω(results[0].Name).Should(Equal("status"))
+		Ω(program.Search("status missing")).Should(BeEmpty())
+	})
+
+	It("should show snippets of bodies", func() {
+		results := program.Search("select")
+		Ω(results).Should(HaveLen(2))
+		Ω(results[0].Snippet).Should(Equal("Captures packets on an interface. Use a filter to select them."))
+		Ω(results[1].Snippet).Should(Equal("Filters select the packets to keep."))
+	})
+
+	It("should print highlighted results with help --search", func() {
+		program.Terminal.SetColorMode(ColorAlways)
+		program.ParseArgs([]string{"exe", "help", "--search", "keep"})
+		Ω(out.String()).Should(Equal("" +
+			"filters\033[2m (topic)\033[0m\n" +
+			"  Filters select the packets to \033[1;33mkeep\033[0m.\n"))
+	})
+
+	It("should match and highlight folded runes of other lengths", func() {
+		program.Topic("units", "temperatures in \u212Aelvin").SetBody("Converts the ſcale.")
+		Ω(program.Search("kelvin")).Should(HaveLen(1))
+		Ω(program.Search("SCALE")).Should(HaveLen(1))
+		program.Terminal.SetColorMode(ColorAlways)
+		program.ParseArgs([]string{"exe", "help", "--search", "kelvin"})
+		Ω(out.String()).Should(Equal("" +
+			"units\033[2m (topic)\033[0m\n" +
+			"  temperatures in \033[1;33m\u212Aelvin\033[0m\n"))
+	})
+
+	It("should report searches without results", func() {
+		program.ParseArgs([]string{"exe", "help", "-s", "zzz"})
+		Ω(out.String()).Should(Equal("No help found for \"zzz\".\n"))
+	})
+})
@@ -0,0 +1,257 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnterminatedQuote is returned by SplitArgs when a quote isn't closed.
+var ErrUnterminatedQuote = errors.New("unterminated quote")
+
+// shellExit unwinds a command run from the shell when it calls Exit.
+type shellExit struct {
+	code int
+}
+
+// SetPrompt configures the prompt displayed by Shell().
+func (p *Program) SetPrompt(prompt string) *Program {
+	p.Prompt = prompt
+	return p
+}
+
+// Shell runs the program as an interactive session. Lines are read from the
+// program input, split into arguments with shell-style quoting and dispatched
+// through the same command tree as ParseArgs. Usage errors and commands that
+// exit are reported without ending the session; `exit`, `quit` or end of
+// input end it. Lines are read with Terminal.ReadLine, completed with
+// Complete and recorded in the terminal history.
+func (p *Program) Shell() error {
+	if p.Exe == "" {
+		p.Exe = path.Base(os.Args[0])
+	}
+	p.Terminal.LoadHistory()
+	if p.Terminal.Completer == nil {
+		p.Terminal.SetCompleter(p.Complete)
+		defer p.Terminal.SetCompleter(nil)
+	}
+
+	for {
+		line, err := p.Terminal.ReadLine(p.prompt())
+		if err == ErrInterrupt {
+			continue
+		}
+		if err == io.EOF {
+			fmt.Fprintln(p.stdout())
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if _, ok := p.Commands[line]; !ok && (line == "exit" || line == "quit") {
+			return nil
+		}
+		p.Terminal.AddHistory(line)
+		p.ExecuteLine(line)
+	}
+}
+
+// ExecuteLine splits `line` into arguments and dispatches them as if they
+// had been passed on the command line, returning the exit code requested by
+// the command (0 if it didn't exit). The program is never terminated.
+func (p *Program) ExecuteLine(line string) (code int) {
+	args, err := SplitArgs(line)
+	if err != nil {
+		fmt.Fprintf(p.stderr(), "\n  error: %v\n\n", err)
+		return 1
+	}
+	if len(args) == 0 {
+		return 0
+	}
+
+	exit := p.Exit
+	p.Exit = func(code int) {
+		panic(shellExit{code: code})
+	}
+	defer func() {
+		p.Exit = exit
+		if r := recover(); r != nil {
+			signal, ok := r.(shellExit)
+			if !ok {
+				panic(r)
+			}
+			code = signal.code
+		}
+	}()
+
+	p.resetValues()
+	p.ParseArgs(append([]string{p.Exe}, args...))
+	return 0
+}
+
+// Complete returns the completion candidates for the last word of `line`:
+// command names for the first word, option flags for words starting with
+// `-`, and commands or topics after `help`.
+func (p *Program) Complete(line string) []string {
+	args, err := SplitArgs(line)
+	if err != nil {
+		return nil
+	}
+	word := ""
+	last, _ := utf8.DecodeLastRuneInString(line)
+	if len(line) > 0 && !unicode.IsSpace(last) && len(args) > 0 {
+		word = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	var words []string
+	if strings.HasPrefix(word, "-") {
+		for _, option := range p.Options {
+			words = append(words, option.Short, option.Long)
+		}
+		if command := p.commandIn(args); command != nil {
+			if command.IsPlugin() {
+				p.describePlugin(command)
+			}
+			for _, option := range command.Options {
+				words = append(words, option.Short, option.Long)
+			}
+		}
+	} else if command := p.commandIn(args); command == nil {
+		for name := range p.Commands {
+			if name != "*" {
+				words = append(words, name)
+			}
+		}
+	} else if command.Command == "help" {
+		p.DescribePlugins()
+		for name := range p.Commands {
+			if name != "*" && name != "help" {
+				words = append(words, name)
+			}
+		}
+		for name := range p.Topics {
+			words = append(words, name)
+		}
+	}
+
+	var candidates []string
+	for _, candidate := range words {
+		if candidate != "" && strings.HasPrefix(candidate, word) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// commandIn returns the command named by the first non-option word in `args`.
+func (p *Program) commandIn(args []string) *Command {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return p.Commands[arg]
+		}
+	}
+	return nil
+}
+
+// prompt returns the shell prompt, defaulting to the binary name.
+func (p *Program) prompt() string {
+	if p.Prompt != "" {
+		return p.Prompt
+	}
+	return p.Exe + "> "
+}
+
+// resetValues clears option and argument values left over from a previous
+// parse so every shell line starts from the program defaults.
+func (p *Program) resetValues() {
+	p.Args = nil
+	for _, option := range p.Options {
+		option.Value = ""
+		option.Count = 0
+	}
+	for _, command := range p.Commands {
+		for _, arg := range command.Args {
+			arg.Value = ""
+		}
+		for _, option := range command.Options {
+			option.Value = ""
+			option.Count = 0
+		}
+	}
+}
+
+// SplitArgs splits a command line into arguments using shell-style rules:
+// words are separated by whitespace, single quotes preserve text literally,
+// double quotes allow backslash escapes of `"`, `\` and `$`, and a backslash
+// outside of quotes escapes the next character.
+func SplitArgs(line string) (args []string, err error) {
+	var word strings.Builder
+	inWord := false
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '\\':
+			inWord = true
+			if i+1 < len(runes) {
+				i++
+				word.WriteRune(runes[i])
+			}
+		case r == '\'':
+			inWord = true
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					closed = true
+					break
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, ErrUnterminatedQuote
+			}
+		case r == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '"' {
+					closed = true
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune(`"\$`, runes[i+1]) {
+					i++
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, ErrUnterminatedQuote
+			}
+		default:
+			inWord = true
+			word.WriteRune(r)
+		}
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
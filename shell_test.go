@@ -0,0 +1,109 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shell", func() {
+
+	Describe("SplitArgs", func() {
+		It("should split on whitespace", func() {
+			Ω(SplitArgs("  tcp   8080 ")).Should(Equal([]string{"tcp", "8080"}))
+		})
+		It("should honor quotes and escapes", func() {
+			Ω(SplitArgs(`say 'hello world' "a \"b\" $c" one\ two ""`)).
+				Should(Equal([]string{"say", "hello world", `a "b" $c`, "one two", ""}))
+		})
+		It("should report unterminated quotes", func() {
+			_, err := SplitArgs(`say "hello`)
+			Ω(err).Should(Equal(ErrUnterminatedQuote))
+		})
+	})
+
+	Describe("Running a session", func() {
+		var program *Program
+		var out, errOut bytes.Buffer
+		var ports []string
+
+		BeforeEach(func() {
+			out.Reset()
+			errOut.Reset()
+			ports = nil
+			program = New()
+			program.Out, program.Err = &out, &errOut
+			program.Exe = "tool"
+			program.Option("-v, --verbose", "display verbose information")
+			program.Command("tcp <port>", "capture TCP packets on <port>").
+				Option("-H, --host <host>", "host address to bind to").
+				SetAction(func(program *Program, command *Command, unknownArgs []string) {
+					ports = append(ports, command.Args[0].Value)
+				})
+			program.Topic("path", "setting the path for reading")
+		})
+
+		It("should dispatch lines and survive usage errors", func() {
+			program.In = strings.NewReader("tcp 80\ntcp\n\ntcp '443'\nexit\ntcp 22\n")
+			Ω(program.Shell()).Should(Succeed())
+			Ω(ports).Should(Equal([]string{"80", "443"}))
+			Ω(errOut.String()).Should(ContainSubstring("missing required argument `port`"))
+			Ω(out.String()).Should(HavePrefix("tool> "))
+		})
+		It("should report the exit code of a line", func() {
+			Ω(program.ExecuteLine("tcp")).Should(Equal(1))
+			Ω(program.ExecuteLine("tcp 80")).Should(Equal(0))
+		})
+		It("should persist history", func() {
+			dir, err := ioutil.TempDir("", "cli-shell")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+			file := filepath.Join(dir, "history")
+			program.Terminal.SetHistoryFile(file)
+			program.In = strings.NewReader("tcp 80\ntcp 80\ntcp 81\n")
+			Ω(program.Shell()).Should(Succeed())
+
+			data, err := ioutil.ReadFile(file)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(data)).Should(Equal("tcp 80\ntcp 81\n"))
+
+			other := New()
+			other.Terminal.SetHistoryFile(file)
+			Ω(other.Terminal.LoadHistory()).Should(Succeed())
+			Ω(other.Terminal.History).Should(Equal([]string{"tcp 80", "tcp 81"}))
+		})
+		It("should trim the history file", func() {
+			dir, err := ioutil.TempDir("", "cli-shell")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+			file := filepath.Join(dir, "history")
+			Ω(ioutil.WriteFile(file, []byte("a\nb\nc\n"), 0600)).Should(Succeed())
+			terminal := program.Terminal.SetHistoryFile(file)
+			terminal.HistorySize = 3
+			Ω(terminal.LoadHistory()).Should(Succeed())
+			for _, line := range []string{"d", "e", "f", "g"} {
+				Ω(terminal.AddHistory(line)).Should(Succeed())
+			}
+
+			data, err := ioutil.ReadFile(file)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(data)).Should(Equal("d\ne\nf\ng\n"))
+			Ω(terminal.History).Should(Equal([]string{"e", "f", "g"}))
+		})
+		It("should complete commands, options and help topics", func() {
+			program.ParseArgs([]string{"tool", "tcp", "80"})
+			Ω(program.Complete("t")).Should(Equal([]string{"tcp"}))
+			Ω(program.Complete("tcp --")).Should(Equal([]string{"--color", "--host", "--log-format", "--no-pager", "--quiet", "--verbose"}))
+			Ω(program.Complete("help ")).Should(Equal([]string{"path", "tcp"}))
+			Ω(program.Complete("tcp ")).Should(BeEmpty())
+			Ω(program.Complete("help Å")).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,76 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Resize is a change in the terminal dimensions.
+type Resize struct {
+	Cols int
+	Rows int
+}
+
+// Size returns the terminal dimensions in columns and rows. The size is read
+// from the terminal the program output is written to, falling back to the
+// $COLUMNS and $LINES variables, so output piped to another program isn't
+// cut to the width of the terminal on stderr or stdin. Unknown dimensions
+// are 0.
+func (t *Terminal) Size() (cols, rows int) {
+	if file, ok := t.Program.stdout().(*os.File); ok {
+		if c, r, err := windowSize(int(file.Fd())); err == nil && c > 0 && r > 0 {
+			return c, r
+		}
+	}
+	return envSize("COLUMNS"), envSize("LINES")
+}
+
+// Resizes subscribes to terminal size changes. The new size is delivered on
+// the returned channel after every resize (only the latest size is kept if
+// the receiver falls behind). Call `stop` to unsubscribe, which closes the
+// channel.
+func (t *Terminal) Resizes() (events <-chan Resize, stop func()) {
+	resizes := make(chan Resize, 1)
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	if len(resizeSignals) > 0 {
+		signal.Notify(signals, resizeSignals...)
+	}
+	go func() {
+		defer close(resizes)
+		for {
+			select {
+			case <-done:
+				return
+			case <-signals:
+				cols, rows := t.Size()
+				// Replace an undelivered size with the latest one
+				select {
+				case <-resizes:
+				default:
+				}
+				resizes <- Resize{Cols: cols, Rows: rows}
+			}
+		}
+	}()
+	var once sync.Once
+	return resizes, func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+// envSize returns a dimension from an environment variable (0 if unset).
+func envSize(name string) int {
+	value, err := strconv.Atoi(strings.TrimSpace(os.Getenv(name)))
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value
+}
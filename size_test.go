@@ -0,0 +1,69 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+
+//go:build linux
+// +build linux
+
+package cli_test
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Terminal size", func() {
+
+	var program *Program
+
+	BeforeEach(func() {
+		program = New()
+		program.Out = &bytes.Buffer{}
+		program.Err = &bytes.Buffer{}
+		program.In = &bytes.Buffer{}
+		os.Setenv("COLUMNS", "100")
+		os.Setenv("LINES", "40")
+	})
+	AfterEach(func() {
+		os.Unsetenv("COLUMNS")
+		os.Unsetenv("LINES")
+	})
+
+	It("should fall back to $COLUMNS and $LINES", func() {
+		cols, rows := program.Terminal.Size()
+		Ω(cols).Should(Equal(100))
+		Ω(rows).Should(Equal(40))
+
+		os.Unsetenv("LINES")
+		_, rows = program.Terminal.Size()
+		Ω(rows).Should(Equal(0))
+	})
+	It("should only read the size of the output terminal", func() {
+		// Pseudo-terminal sized 132x50 as stderr and stdin
+		master, tty := openPTY(132, 50)
+		defer master.Close()
+		defer tty.Close()
+		program.Err = tty
+		program.In = tty
+
+		cols, rows := program.Terminal.Size()
+		Ω(cols).Should(Equal(100))
+		Ω(rows).Should(Equal(40))
+
+		program.Out = tty
+		cols, rows = program.Terminal.Size()
+		Ω(cols).Should(Equal(132))
+		Ω(rows).Should(Equal(50))
+	})
+	It("should deliver resize events until stopped", func() {
+		events, stop := program.Terminal.Resizes()
+		Ω(syscall.Kill(os.Getpid(), syscall.SIGWINCH)).Should(Succeed())
+		Eventually(events).Should(Receive(Equal(Resize{Cols: 100, Rows: 40})))
+		stop()
+		stop()
+		Eventually(events).Should(BeClosed())
+	})
+})
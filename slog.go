@@ -0,0 +1,79 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+
+//go:build go1.21
+// +build go1.21
+
+package cli
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Returns a log/slog handler that writes records through the terminal, so
+// `slog.New(program.Terminal.Handler())` honors -v/-q, indentation and the
+// log format. Slog levels map directly onto terminal levels.
+func (t *Terminal) Handler() slog.Handler {
+	return &slogHandler{terminal: t}
+}
+
+// slogHandler adapts the terminal to the slog.Handler interface.
+type slogHandler struct {
+	terminal *Terminal
+	attrs    []interface{} // Attributes added with WithAttrs
+	group    string        // Group prefix for attribute keys
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.terminal.Enabled(Level(level))
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(_ context.Context, record slog.Record) error {
+	keyvals := append([]interface{}{}, h.attrs...)
+	record.Attrs(func(a slog.Attr) bool {
+		keyvals = appendSlogAttr(keyvals, h.group, a)
+		return true
+	})
+	h.terminal.Log(Level(record.Level), record.Message, keyvals...)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.attrs = append([]interface{}{}, h.attrs...)
+	for _, a := range attrs {
+		handler.attrs = appendSlogAttr(handler.attrs, h.group, a)
+	}
+	return &handler
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	handler := *h
+	handler.group = h.group + name + "."
+	return &handler
+}
+
+// Flattens an slog attribute (expanding groups) into key value pairs.
+func appendSlogAttr(keyvals []interface{}, prefix string, a slog.Attr) []interface{} {
+	value := a.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, member := range value.Group() {
+			keyvals = appendSlogAttr(keyvals, prefix, member)
+		}
+		return keyvals
+	}
+	if a.Key == "" {
+		return keyvals
+	}
+	return append(keyvals, prefix+a.Key, value.Any())
+}
@@ -0,0 +1,36 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+
+//go:build go1.21
+// +build go1.21
+
+package cli_test
+
+import (
+	"bytes"
+	"log/slog"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Slog handler", func() {
+
+	var out bytes.Buffer
+	var terminal *Terminal
+
+	BeforeEach(func() {
+		out.Reset()
+		program := New()
+		program.Out = &out
+		terminal = program.Terminal.SetTimeFormat("T")
+	})
+
+	It("should handle log/slog records", func() {
+		terminal.SetLogFormat(LogFormatLogfmt)
+		logger := slog.New(terminal.Handler()).With("request", 7).WithGroup("db")
+		logger.Debug("hidden")
+		logger.Info("query", "rows", 12)
+		Ω(out.String()).Should(Equal("time=T level=INFO msg=query scope=0 request=7 db.rows=12\n"))
+	})
+})
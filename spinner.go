@@ -0,0 +1,170 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"time"
+)
+
+// Spinner frame sets.
+var (
+	SpinnerDots   = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	SpinnerLine   = []string{"-", "\\", "|", "/"}
+	SpinnerCircle = []string{"◐", "◓", "◑", "◒"}
+	SpinnerArrow  = []string{"←", "↖", "↑", "↗", "→", "↘", "↓", "↙"}
+)
+
+// Spinner animates a message for operations without a known amount of work.
+// Create spinners with Terminal.Spinner and finish them with Success or Fail.
+// While a spinner runs, log messages written through the terminal are
+// printed above it. Only one spinner should run on a terminal at a time.
+// Fatal, Error and Program.Exit clear a running spinner and show the cursor
+// before reporting the error or exiting.
+//
+// When cursor control is disabled (output isn't a terminal, see
+// Terminal.CursorEnabled) nothing is animated and only the final line is
+// printed.
+type Spinner struct {
+	Terminal *Terminal
+	Message  string
+	Frames   []string
+	Interval time.Duration // Time between frames
+
+	frame int
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// Starts a spinner displaying `message` on its own goroutine.
+func (t *Terminal) Spinner(message string) *Spinner {
+	s := &Spinner{
+		Terminal: t,
+		Message:  message,
+		Frames:   SpinnerDots,
+		Interval: 80 * time.Millisecond,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.CursorEnabled() {
+		close(s.done)
+		return s
+	}
+	t.spinner = s
+	t.Hide()
+	s.draw()
+	go s.run()
+	return s
+}
+
+// Sets the animation frames (for example SpinnerLine).
+func (s *Spinner) SetFrames(frames []string) *Spinner {
+	s.Terminal.mu.Lock()
+	defer s.Terminal.mu.Unlock()
+	if len(frames) > 0 {
+		s.Frames = frames
+		s.frame = 0
+	}
+	return s
+}
+
+// Replaces the message displayed next to the spinner.
+func (s *Spinner) Update(message string) *Spinner {
+	s.Terminal.mu.Lock()
+	defer s.Terminal.mu.Unlock()
+	s.Message = message
+	if s.Terminal.spinner == s {
+		s.draw()
+	}
+	return s
+}
+
+// Stops the spinner and replaces it with a check mark and the message (or
+// `message` if provided).
+func (s *Spinner) Success(message ...string) {
+	s.finish(s.Terminal.Paint("✓", Fg(Green)), message)
+}
+
+// Stops the spinner and replaces it with a cross and the message (or
+// `message` if provided).
+func (s *Spinner) Fail(message ...string) {
+	s.finish(s.Terminal.Paint("✗", Fg(Red)), message)
+}
+
+// run advances the animation until the spinner is stopped.
+func (s *Spinner) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.Terminal.mu.Lock()
+			s.frame = (s.frame + 1) % len(s.Frames)
+			s.draw()
+			s.Terminal.mu.Unlock()
+		}
+	}
+}
+
+// finish stops the animation and prints the final line once.
+func (s *Spinner) finish(mark string, message []string) {
+	if !s.halt() {
+		return
+	}
+	t := s.Terminal
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(message) > 0 {
+		s.Message = message[0]
+	}
+	t.Print(t.indentation() + mark + " " + s.Message).Nl()
+}
+
+// halt stops the animation, removes the spinner line and shows the cursor.
+// Returns false if the spinner was already stopped.
+func (s *Spinner) halt() bool {
+	select {
+	case <-s.stop:
+		return false
+	default:
+		close(s.stop)
+	}
+	<-s.done
+
+	t := s.Terminal
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.spinner == s {
+		t.spinner = nil
+		t.Print("\r").ClearLine()
+		t.Show()
+	}
+	return true
+}
+
+// stopSpinner halts the running spinner (if any) without a final line, so
+// errors can be printed and the program can exit with the cursor visible.
+func (t *Terminal) stopSpinner() {
+	t.mu.Lock()
+	s := t.spinner
+	t.mu.Unlock()
+	if s != nil {
+		s.halt()
+	}
+}
+
+// draw displays the current frame and message. Must be called with the
+// terminal lock held.
+func (s *Spinner) draw() {
+	t := s.Terminal
+	t.Print("\r").ClearLine().Print(t.indentation() + s.Frames[s.frame] + " " + s.Message)
+}
+
+// clear removes the spinner line so other output can be printed in its place.
+// Must be called with the terminal lock held.
+func (s *Spinner) clear() {
+	s.Terminal.Print("\r").ClearLine()
+}
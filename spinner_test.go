@@ -0,0 +1,53 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spinner", func() {
+
+	var program *Program
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		out.Reset()
+		program = New()
+		program.Out = &out
+	})
+
+	It("should only print the final line if output isn't a terminal", func() {
+		spinner := program.Terminal.Spinner("working")
+		spinner.Update("still working")
+		program.Terminal.Info("halfway")
+		spinner.Success()
+		spinner.Fail()
+		Ω(out.String()).Should(Equal("halfway\n✓ still working\n"))
+	})
+	It("should animate and print log messages above the spinner", func() {
+		program.Terminal.SetColorMode(ColorAlways)
+		spinner := program.Terminal.Spinner("working").SetFrames(SpinnerLine)
+		program.Terminal.Info("halfway")
+		spinner.Update("almost")
+		spinner.Fail("failed")
+		Ω(out.String()).Should(ContainSubstring("\r\033[2Khalfway\n\r\033[2K- working"))
+		Ω(out.String()).Should(ContainSubstring("\r\033[2K- almost"))
+		Ω(out.String()).Should(HaveSuffix("\033[31m✗\033[0m failed\n"))
+	})
+	It("should clear the spinner and show the cursor when the program exits", func() {
+		var errOut bytes.Buffer
+		program.Err = &errOut
+		program.Exit = func(int) {}
+		program.Terminal.SetColorMode(ColorAlways)
+		spinner := program.Terminal.Spinner("working")
+		program.Terminal.Fatal("broken")
+		Ω(out.String()).Should(HaveSuffix("\r\033[2K\033[?25h"))
+		Ω(errOut.String()).Should(Equal("broken\n"))
+		spinner.Success()
+		Ω(out.String()).Should(HaveSuffix("\033[?25h"))
+	})
+})
@@ -0,0 +1,73 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Structured logging", func() {
+
+	var program *Program
+	var terminal *Terminal
+	var out, errOut bytes.Buffer
+
+	BeforeEach(func() {
+		out.Reset()
+		errOut.Reset()
+		program = New()
+		program.Out, program.Err = &out, &errOut
+		program.Exit = func(int) {}
+		program.Command("run", "run the program")
+		terminal = program.Terminal.SetTimeFormat("T")
+	})
+
+	It("should select the format with --log-format", func() {
+		program.ParseArgs([]string{"tool", "--log-format=json", "run"})
+		Ω(terminal.Structured()).Should(BeTrue())
+		terminal.PushIndent().Info("started")
+		var record map[string]interface{}
+		Ω(json.Unmarshal(out.Bytes(), &record)).Should(Succeed())
+		Ω(record).Should(Equal(map[string]interface{}{"time": "T", "level": "INFO", "msg": "started", "scope": 1.0}))
+	})
+	It("should reject unknown log formats", func() {
+		code := -1
+		program.Exit = func(c int) { code = c }
+		Ω(program.ParseArgs([]string{"tool", "--log-format", "xml", "run"})).Should(BeNil())
+		Ω(code).Should(Equal(1))
+		Ω(errOut.String()).Should(ContainSubstring("error: option `--log-format <format>` has invalid value `xml`, expected one of: text, json, logfmt"))
+	})
+	It("should leave a program's own --log-format option alone", func() {
+		program.Option("--log-format <format>", "format of the log file to read")
+		program.ParseArgs([]string{"tool", "--log-format", "apache", "run"})
+		Ω(program.OptionFor("--log-format").Value).Should(Equal("apache"))
+		Ω(terminal.Structured()).Should(BeFalse())
+	})
+	It("should emit JSON records with attributes and error chains", func() {
+		terminal.SetLogFormat(LogFormatJSON)
+		err := fmt.Errorf("loading config: %w", errors.New("file not found"))
+		terminal.Log(LevelWarn, "fallback", "path", "/etc/app", "attempt", 2, "error", err)
+		Ω(errOut.String()).Should(Equal(`{"time":"T","level":"WARN","msg":"fallback","scope":0,"path":"/etc/app","attempt":2,` +
+			`"error":"loading config: file not found","error_chain":["loading config: file not found","file not found"]}` + "\n"))
+	})
+	It("should emit logfmt records", func() {
+		terminal.SetLogFormat(LogFormatLogfmt)
+		terminal.Log(LevelInfo, "copied files", "count", 3, "dest", "my dir")
+		Ω(out.String()).Should(Equal(`time=T level=INFO msg="copied files" scope=0 count=3 dest="my dir"` + "\n"))
+	})
+	It("should append attributes to text records", func() {
+		terminal.SetTimeFormat("").Log(LevelInfo, "copied", "count", 3)
+		Ω(out.String()).Should(Equal("copied count=3\n"))
+	})
+	It("should log errors as records", func() {
+		terminal.SetLogFormat(LogFormatLogfmt)
+		terminal.Error(errors.New("boom"), "failed")
+		Ω(errOut.String()).Should(Equal("time=T level=ERROR msg=failed scope=0 error=boom\n"))
+	})
+})
@@ -0,0 +1,127 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Style is a set of terminal text attributes (SGR parameters) such as colors,
+// bold or underline. Styles are composed with With() or NewStyle() and the
+// zero Style leaves text unchanged.
+type Style struct {
+	codes []string
+}
+
+// Text attribute styles.
+var (
+	Bold          = Style{codes: []string{"1"}}
+	Dim           = Style{codes: []string{"2"}}
+	Italic        = Style{codes: []string{"3"}}
+	Underline     = Style{codes: []string{"4"}}
+	Blink         = Style{codes: []string{"5"}}
+	Inverse       = Style{codes: []string{"7"}}
+	Hidden        = Style{codes: []string{"8"}}
+	Strikethrough = Style{codes: []string{"9"}}
+)
+
+// resetSequence resets all terminal attributes.
+const resetSequence = "\033[0m"
+
+// NewStyle combines `styles` into a single style.
+func NewStyle(styles ...Style) Style {
+	return Style{}.With(styles...)
+}
+
+// Fg returns a style with one of the eight basic colors (Black through White)
+// as the foreground color.
+func Fg(color int) Style {
+	return Style{codes: []string{strconv.Itoa(30 + color)}}
+}
+
+// Bg returns a style with one of the eight basic colors (Black through White)
+// as the background color.
+func Bg(color int) Style {
+	return Style{codes: []string{strconv.Itoa(40 + color)}}
+}
+
+// BrightFg returns a style with the bright variant of one of the eight basic
+// colors as the foreground color.
+func BrightFg(color int) Style {
+	return Style{codes: []string{strconv.Itoa(90 + color)}}
+}
+
+// BrightBg returns a style with the bright variant of one of the eight basic
+// colors as the background color.
+func BrightBg(color int) Style {
+	return Style{codes: []string{strconv.Itoa(100 + color)}}
+}
+
+// Fg256 returns a style with a 256-color palette foreground color.
+func Fg256(color uint8) Style {
+	return Style{codes: []string{"38", "5", strconv.Itoa(int(color))}}
+}
+
+// Bg256 returns a style with a 256-color palette background color.
+func Bg256(color uint8) Style {
+	return Style{codes: []string{"48", "5", strconv.Itoa(int(color))}}
+}
+
+// FgRGB returns a style with a 24-bit (truecolor) foreground color.
+func FgRGB(r, g, b uint8) Style {
+	return Style{codes: []string{"38", "2", strconv.Itoa(int(r)), strconv.Itoa(int(g)), strconv.Itoa(int(b))}}
+}
+
+// BgRGB returns a style with a 24-bit (truecolor) background color.
+func BgRGB(r, g, b uint8) Style {
+	return Style{codes: []string{"48", "2", strconv.Itoa(int(r)), strconv.Itoa(int(g)), strconv.Itoa(int(b))}}
+}
+
+// With returns a new style combining the style with `styles`. Later colors
+// override earlier ones when the text is displayed.
+func (s Style) With(styles ...Style) Style {
+	codes := append([]string{}, s.codes...)
+	for _, style := range styles {
+		codes = append(codes, style.codes...)
+	}
+	return Style{codes: codes}
+}
+
+// IsZero returns true if the style has no attributes.
+func (s Style) IsZero() bool {
+	return len(s.codes) == 0
+}
+
+// Sequence returns the escape sequence that turns the style on.
+func (s Style) Sequence() string {
+	if s.IsZero() {
+		return ""
+	}
+	return "\033[" + strings.Join(s.codes, ";") + "m"
+}
+
+// Wrap returns `text` with the style turned on before it and all attributes
+// reset after it.
+func (s Style) Wrap(text string) string {
+	if s.IsZero() {
+		return text
+	}
+	return s.Sequence() + text + resetSequence
+}
+
+// Sprint formats using the default formats and wraps the result in the style.
+func (s Style) Sprint(a ...interface{}) string {
+	return s.Wrap(fmt.Sprint(a...))
+}
+
+// Sprintf formats according to a format specifier and wraps the result in
+// the style.
+func (s Style) Sprintf(format string, a ...interface{}) string {
+	return s.Wrap(fmt.Sprintf(format, a...))
+}
+
+// Styled wraps `text` in the combination of `styles`, resetting afterwards.
+func Styled(text string, styles ...Style) string {
+	return NewStyle(styles...).Wrap(text)
+}
@@ -0,0 +1,119 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+	"os"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Styles", func() {
+
+	It("should produce SGR sequences for attributes and colors", func() {
+		Ω(Bold.Sequence()).Should(Equal("\033[1m"))
+		Ω(NewStyle(Bold, Underline, Fg(Red), Bg(Black)).Sequence()).Should(Equal("\033[1;4;31;40m"))
+		Ω(BrightFg(Cyan).Sequence()).Should(Equal("\033[96m"))
+		Ω(Fg256(208).With(Bg256(17)).Sequence()).Should(Equal("\033[38;5;208;48;5;17m"))
+		Ω(FgRGB(255, 128, 0).With(BgRGB(0, 0, 0)).Sequence()).Should(Equal("\033[38;2;255;128;0;48;2;0;0;0m"))
+	})
+	It("should wrap text and reset afterwards", func() {
+		Ω(Styled("warning", Bold, Fg(Yellow))).Should(Equal("\033[1;33mwarning\033[0m"))
+		Ω(Italic.Sprintf("%d items", 3)).Should(Equal("\033[3m3 items\033[0m"))
+		Ω(Style{}.Wrap("plain")).Should(Equal("plain"))
+	})
+	It("should not modify composed styles", func() {
+		base := NewStyle(Bold)
+		red := base.With(Fg(Red))
+		base.With(Fg(Green))
+		Ω(red.Sequence()).Should(Equal("\033[1;31m"))
+	})
+	It("should print well formed colors on the terminal", func() {
+		var out bytes.Buffer
+		program := New()
+		program.Out = &out
+		program.Terminal.SetColorMode(ColorAlways).Color(Red, White).Print("text").Reset()
+		Ω(out.String()).Should(Equal("\033[31;47mtext\033[0m"))
+	})
+
+	Describe("Color detection", func() {
+		var out bytes.Buffer
+		var program *Program
+		var environment map[string]string
+
+		BeforeEach(func() {
+			out.Reset()
+			program = New()
+			program.Out = &out
+			environment = map[string]string{}
+			for _, key := range []string{"NO_COLOR", "CLICOLOR_FORCE", "TERM"} {
+				environment[key] = os.Getenv(key)
+				os.Unsetenv(key)
+			}
+		})
+
+		AfterEach(func() {
+			for key, value := range environment {
+				os.Setenv(key, value)
+			}
+		})
+
+		It("should not emit escapes when output isn't a terminal", func() {
+			program.Terminal.Style(Bold).Print("text").Up(1).Reset()
+			Ω(out.String()).Should(Equal("text"))
+			Ω(program.Terminal.Paint("text", Bold)).Should(Equal("text"))
+		})
+		It("should honor CLICOLOR_FORCE and NO_COLOR", func() {
+			os.Setenv("CLICOLOR_FORCE", "1")
+			Ω(program.Terminal.ColorEnabled()).Should(BeTrue())
+			os.Setenv("NO_COLOR", "1")
+			Ω(program.Terminal.ColorEnabled()).Should(BeFalse())
+		})
+		It("should honor the --color option", func() {
+			os.Setenv("NO_COLOR", "1")
+			program.Command("status", "show status")
+			program.ParseArgs([]string{"exe", "--color=always", "status"})
+			Ω(program.Terminal.ColorEnabled()).Should(BeTrue())
+			Ω(program.Terminal.ErrColorEnabled()).Should(BeTrue())
+			program.ParseArgs([]string{"exe", "--color=never", "status"})
+			os.Setenv("CLICOLOR_FORCE", "1")
+			Ω(program.Terminal.ColorEnabled()).Should(BeFalse())
+		})
+		It("should only bind --color values given with =", func() {
+			program.Command("status", "show status")
+			command := program.ParseArgs([]string{"exe", "--color", "status"})
+			Ω(command).ShouldNot(BeNil())
+			Ω(command.Command).Should(Equal("status"))
+			Ω(program.OptionFor("--color").Value).Should(Equal("true"))
+			Ω(program.Terminal.ColorEnabled()).Should(BeTrue())
+		})
+		It("should reject invalid --color values", func() {
+			code := -1
+			program.Exit = func(c int) { code = c }
+			program.Err = &out
+			Ω(program.ParseArgs([]string{"exe", "--color=blue"})).Should(BeNil())
+			Ω(code).Should(Equal(1))
+			Ω(out.String()).Should(ContainSubstring("error: option `--color[=<when>]` has invalid value `blue`, expected one of: auto, always, never"))
+		})
+		It("should register --color as a long only option", func() {
+			program.Command("status", "show status")
+			program.ParseArgs([]string{"exe", "status"})
+			option := program.OptionFor("--color")
+			Ω(option).ShouldNot(BeNil())
+			Ω(option.Short).Should(Equal(""))
+			Ω(option.Name).Should(Equal("color"))
+			Ω(option.Inline).Should(BeTrue())
+			Ω(option.Default).Should(Equal(ColorAuto))
+		})
+		It("should leave a program's own --color option alone", func() {
+			program.Option("-c, --color <color>", "color to use")
+			program.Command("status", "show status")
+			program.ParseArgs([]string{"exe", "--color", "blue", "status"})
+			Ω(program.OptionFor("--color").Value).Should(Equal("blue"))
+			Ω(program.Options).ShouldNot(HaveKey("--color[=<when>]"))
+			Ω(program.Terminal.ColorEnabled()).Should(BeFalse())
+		})
+	})
+})
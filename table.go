@@ -0,0 +1,410 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Align is the horizontal alignment of a table column.
+type Align int
+
+// Column alignments.
+const (
+	AlignLeft Align = iota
+	AlignRight
+	AlignCenter
+)
+
+// Border is the style of lines drawn around and between table cells.
+type Border int
+
+// Table border styles.
+const (
+	BorderNone    Border = iota // Columns separated by spaces
+	BorderASCII                 // +---+ boxes
+	BorderUnicode               // ┌───┐ boxes
+)
+
+// Table output formats.
+const (
+	TableFormatText = "table"
+	TableFormatCSV  = "csv"
+	TableFormatTSV  = "tsv"
+	TableFormatJSON = "json"
+)
+
+// Cell is a table cell with a style applied when color is enabled.
+type Cell struct {
+	Text  string
+	Style Style
+}
+
+// Table renders rows of cells in aligned columns. Create tables with
+// Terminal.Table, add rows with Row and output them with Print.
+type Table struct {
+	Terminal   *Terminal
+	Headers    []string
+	Rows       [][]Cell
+	Aligns     map[int]Align // Column alignments (left by default)
+	MaxWidths  map[int]int   // Maximum column widths (0 for no limit)
+	Border     Border
+	Width      int    // Maximum table width (0 fits the terminal width)
+	Ellipsis   string // Marks truncated cells
+	Format     string // Output format ("" picks text or PipeFormat)
+	PipeFormat string // Format used when output isn't a terminal ("" for text)
+}
+
+// borderRunes are the characters used to draw a border: horizontal line,
+// vertical line, then the top, middle and bottom left, cross and right joins.
+type borderRunes struct {
+	horizontal, vertical string
+	top, middle, bottom  [3]string
+}
+
+var borders = map[Border]borderRunes{
+	BorderASCII: {
+		horizontal: "-", vertical: "|",
+		top: [3]string{"+", "+", "+"}, middle: [3]string{"+", "+", "+"}, bottom: [3]string{"+", "+", "+"},
+	},
+	BorderUnicode: {
+		horizontal: "─", vertical: "│",
+		top: [3]string{"┌", "┬", "┐"}, middle: [3]string{"├", "┼", "┤"}, bottom: [3]string{"└", "┴", "┘"},
+	},
+}
+
+// Creates a table with the provided column headers (which may be empty)
+func (t *Terminal) Table(headers ...string) *Table {
+	return &Table{
+		Terminal:  t,
+		Headers:   headers,
+		Aligns:    map[int]Align{},
+		MaxWidths: map[int]int{},
+		Ellipsis:  "…",
+	}
+}
+
+// Row adds a row to the table. Cells are displayed with fmt.Sprint unless
+// they are a Cell.
+func (tb *Table) Row(cells ...interface{}) *Table {
+	row := make([]Cell, len(cells))
+	for i, cell := range cells {
+		switch c := cell.(type) {
+		case Cell:
+			row[i] = c
+		case string:
+			row[i] = Cell{Text: c}
+		default:
+			row[i] = Cell{Text: fmt.Sprint(c)}
+		}
+	}
+	tb.Rows = append(tb.Rows, row)
+	return tb
+}
+
+// SetAlign sets the alignment of a column (0-based).
+func (tb *Table) SetAlign(column int, align Align) *Table {
+	tb.Aligns[column] = align
+	return tb
+}
+
+// SetMaxWidth limits the width of a column (0-based); longer cells are
+// truncated with the ellipsis.
+func (tb *Table) SetMaxWidth(column, width int) *Table {
+	tb.MaxWidths[column] = width
+	return tb
+}
+
+// SetBorder sets the border style.
+func (tb *Table) SetBorder(border Border) *Table {
+	tb.Border = border
+	return tb
+}
+
+// SetWidth sets the maximum table width (0 fits the terminal width).
+func (tb *Table) SetWidth(width int) *Table {
+	tb.Width = width
+	return tb
+}
+
+// SetFormat sets the output format (TableFormatText, TableFormatCSV,
+// TableFormatTSV or TableFormatJSON).
+func (tb *Table) SetFormat(format string) *Table {
+	tb.Format = format
+	return tb
+}
+
+// SetPipeFormat sets the output format used when output isn't a terminal,
+// so the same table can be read by other programs.
+func (tb *Table) SetPipeFormat(format string) *Table {
+	tb.PipeFormat = format
+	return tb
+}
+
+// Print renders the table to the program output.
+func (tb *Table) Print() error {
+	out := tb.Terminal.Program.stdout()
+	format := tb.Format
+	if format == "" {
+		format = TableFormatText
+		if tb.PipeFormat != "" && !writerIsTerminal(out) {
+			format = tb.PipeFormat
+		}
+	}
+	return tb.Render(out, format)
+}
+
+// Render writes the table to `w` in the provided format.
+func (tb *Table) Render(w io.Writer, format string) error {
+	switch format {
+	case TableFormatCSV, TableFormatTSV:
+		writer := csv.NewWriter(w)
+		if format == TableFormatTSV {
+			writer.Comma = '\t'
+		}
+		if len(tb.Headers) > 0 {
+			writer.Write(tb.Headers)
+		}
+		for _, row := range tb.Rows {
+			writer.Write(cellTexts(row))
+		}
+		writer.Flush()
+		return writer.Error()
+	case TableFormatJSON:
+		return tb.renderJSON(w)
+	case TableFormatText, "":
+		_, err := io.WriteString(w, tb.String())
+		return err
+	}
+	return fmt.Errorf("unknown table format `%s`", format)
+}
+
+// String renders the table as aligned text.
+func (tb *Table) String() string {
+	widths := tb.fit(tb.columnWidths())
+	color := tb.Terminal.ColorEnabled()
+	border, boxed := borders[tb.Border]
+
+	var b strings.Builder
+	line := func(joins [3]string) {
+		if !boxed {
+			return
+		}
+		b.WriteString(joins[0])
+		for i, width := range widths {
+			if i > 0 {
+				b.WriteString(joins[1])
+			}
+			b.WriteString(strings.Repeat(border.horizontal, width+2))
+		}
+		b.WriteString(joins[2])
+		b.WriteString("\n")
+	}
+	row := func(cells []Cell, header bool) {
+		if boxed {
+			b.WriteString(border.vertical + " ")
+		}
+		for i, width := range widths {
+			if i > 0 {
+				if boxed {
+					b.WriteString(" " + border.vertical + " ")
+				} else {
+					b.WriteString("  ")
+				}
+			}
+			var cell Cell
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			if header {
+				cell.Style = Bold
+			}
+			text := pad(truncate(cell.Text, width, tb.Ellipsis), width, tb.Aligns[i])
+			if !boxed && i == len(widths)-1 {
+				text = strings.TrimRight(text, " ")
+			}
+			if color && !cell.Style.IsZero() {
+				text = cell.Style.Wrap(text)
+			}
+			b.WriteString(text)
+		}
+		if boxed {
+			b.WriteString(" " + border.vertical)
+		}
+		b.WriteString("\n")
+	}
+
+	line(border.top)
+	if len(tb.Headers) > 0 {
+		headers := make([]Cell, len(tb.Headers))
+		for i, header := range tb.Headers {
+			headers[i] = Cell{Text: header}
+		}
+		row(headers, true)
+		line(border.middle)
+	}
+	for _, cells := range tb.Rows {
+		row(cells, false)
+	}
+	line(border.bottom)
+	return b.String()
+}
+
+// columnWidths returns the natural width of every column, limited by the
+// column maximum widths.
+func (tb *Table) columnWidths() []int {
+	columns := len(tb.Headers)
+	for _, row := range tb.Rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	widths := make([]int, columns)
+	measure := func(i int, text string) {
+		if width := utf8.RuneCountInString(text); width > widths[i] {
+			widths[i] = width
+		}
+	}
+	for i, header := range tb.Headers {
+		measure(i, header)
+	}
+	for _, row := range tb.Rows {
+		for i, cell := range row {
+			measure(i, cell.Text)
+		}
+	}
+	for i := range widths {
+		if max := tb.MaxWidths[i]; max > 0 && widths[i] > max {
+			widths[i] = max
+		}
+	}
+	return widths
+}
+
+// fit shrinks the widest columns until the table fits the available width.
+func (tb *Table) fit(widths []int) []int {
+	available := tb.Width
+	if available == 0 {
+		available, _ = tb.Terminal.Size()
+	}
+	if available <= 0 || len(widths) == 0 {
+		return widths
+	}
+	// Space used by separators and borders
+	overhead := 2 * (len(widths) - 1)
+	if _, boxed := borders[tb.Border]; boxed {
+		overhead = 3*(len(widths)-1) + 4
+	}
+	const minWidth = 3
+	for {
+		total := overhead
+		widest := 0
+		for i, width := range widths {
+			total += width
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= available || widths[widest] <= minWidth {
+			return widths
+		}
+		widths[widest]--
+	}
+}
+
+// renderJSON writes the rows as a JSON array of objects keyed by header, or
+// of arrays when there are no headers.
+func (tb *Table) renderJSON(w io.Writer) error {
+	var rows []interface{}
+	for _, row := range tb.Rows {
+		texts := cellTexts(row)
+		if len(tb.Headers) == 0 {
+			rows = append(rows, texts)
+			continue
+		}
+		object := make(jsonObject, len(tb.Headers))
+		for i, header := range tb.Headers {
+			object[i].key = header
+			if i < len(texts) {
+				object[i].value = texts[i]
+			}
+		}
+		rows = append(rows, object)
+	}
+	if rows == nil {
+		rows = []interface{}{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+// jsonObject is a JSON object keeping its keys in column order, including
+// repeated headers.
+type jsonObject []struct{ key, value string }
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// cellTexts returns the text of every cell in a row.
+func cellTexts(row []Cell) []string {
+	texts := make([]string, len(row))
+	for i, cell := range row {
+		texts[i] = cell.Text
+	}
+	return texts
+}
+
+// truncate shortens `text` to `width` characters, ending it with `ellipsis`.
+func truncate(text string, width int, ellipsis string) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	mark := []rune(ellipsis)
+	if len(mark) >= width {
+		return string(runes[:width])
+	}
+	return string(runes[:width-len(mark)]) + ellipsis
+}
+
+// pad aligns `text` within `width` characters.
+func pad(text string, width int, align Align) string {
+	space := width - utf8.RuneCountInString(text)
+	if space <= 0 {
+		return text
+	}
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", space) + text
+	case AlignCenter:
+		left := space / 2
+		return strings.Repeat(" ", left) + text + strings.Repeat(" ", space-left)
+	}
+	return text + strings.Repeat(" ", space)
+}
@@ -0,0 +1,83 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tables", func() {
+
+	var program *Program
+	var out bytes.Buffer
+	var table *Table
+
+	BeforeEach(func() {
+		out.Reset()
+		program = New()
+		program.Out = &out
+		table = program.Terminal.Table("NAME", "SIZE").
+			Row("alpha", 12).
+			Row("beta.tar.gz", 3400).
+			SetAlign(1, AlignRight)
+	})
+
+	It("should align columns without borders", func() {
+		Ω(table.String()).Should(Equal("" +
+			"NAME         SIZE\n" +
+			"alpha          12\n" +
+			"beta.tar.gz  3400\n"))
+	})
+	It("should draw ASCII borders", func() {
+		Ω(table.SetBorder(BorderASCII).String()).Should(Equal("" +
+			"+-------------+------+\n" +
+			"| NAME        | SIZE |\n" +
+			"+-------------+------+\n" +
+			"| alpha       |   12 |\n" +
+			"| beta.tar.gz | 3400 |\n" +
+			"+-------------+------+\n"))
+	})
+	It("should draw Unicode borders", func() {
+		Ω(table.SetBorder(BorderUnicode).SetAlign(1, AlignCenter).String()).Should(Equal("" +
+			"┌─────────────┬──────┐\n" +
+			"│ NAME        │ SIZE │\n" +
+			"├─────────────┼──────┤\n" +
+			"│ alpha       │  12  │\n" +
+			"│ beta.tar.gz │ 3400 │\n" +
+			"└─────────────┴──────┘\n"))
+	})
+	It("should truncate cells to fit the width", func() {
+		Ω(table.SetWidth(13).String()).Should(Equal("" +
+			"NAME     SIZE\n" +
+			"alpha      12\n" +
+			"beta.t…  3400\n"))
+		Ω(table.SetWidth(0).SetMaxWidth(0, 4).String()).Should(ContainSubstring("bet…  3400"))
+	})
+	It("should color cells and headers when color is enabled", func() {
+		program.Terminal.SetColorMode(ColorAlways)
+		table = program.Terminal.Table("A").Row(Cell{Text: "x", Style: Fg(Red)})
+		Ω(table.String()).Should(Equal("\033[1mA\033[0m\n\033[31mx\033[0m\n"))
+	})
+	It("should emit CSV, TSV and JSON", func() {
+		Ω(table.Render(&out, TableFormatCSV)).Should(Succeed())
+		Ω(out.String()).Should(Equal("NAME,SIZE\nalpha,12\nbeta.tar.gz,3400\n"))
+		out.Reset()
+		Ω(table.Render(&out, TableFormatTSV)).Should(Succeed())
+		Ω(out.String()).Should(Equal("NAME\tSIZE\nalpha\t12\nbeta.tar.gz\t3400\n"))
+		out.Reset()
+		Ω(table.Render(&out, TableFormatJSON)).Should(Succeed())
+		Ω(out.String()).Should(MatchJSON(`[{"NAME":"alpha","SIZE":"12"},{"NAME":"beta.tar.gz","SIZE":"3400"}]`))
+	})
+	It("should keep the header order and repeated headers in JSON", func() {
+		table = program.Terminal.Table("SIZE", "NAME", "NAME").Row(Cell{Text: "12"}, Cell{Text: "alpha"})
+		Ω(table.Render(&out, TableFormatJSON)).Should(Succeed())
+		Ω(out.String()).Should(Equal("[\n  {\n    \"SIZE\": \"12\",\n    \"NAME\": \"alpha\",\n    \"NAME\": \"\"\n  }\n]\n"))
+	})
+	It("should use the pipe format when output isn't a terminal", func() {
+		Ω(table.SetPipeFormat(TableFormatCSV).Print()).Should(Succeed())
+		Ω(out.String()).Should(Equal("NAME,SIZE\nalpha,12\nbeta.tar.gz,3400\n"))
+	})
+})
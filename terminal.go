@@ -2,20 +2,43 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
-	"reflect"
 	"strings"
+	"sync"
+	"time"
 )
 
 func NewTerminal(program *Program) *Terminal {
-	return &Terminal{Program: program, IndentSize: 2}
+	return &Terminal{Program: program, IndentSize: 2, HistorySize: 500}
 }
 
 type Terminal struct {
-	Program    *Program // The program this terminal belongs to
-	Indent     uint     // Current ident level for stdout statements
-	IndentSize uint     // Number of spaces to indent stdout statements
+	Program     *Program  // The program this terminal belongs to
+	Indent      uint      // Current ident level for stdout statements
+	IndentSize  uint      // Number of spaces to indent stdout statements
+	History     []string  // Previously entered input lines, oldest first
+	HistoryFile string    // File history is loaded from and saved to (if set)
+	HistorySize int       // Maximum number of history lines kept
+	Completer   Completer // Completion function used by ReadLine (if set)
+	ColorMode   string    // ColorAuto, ColorAlways or ColorNever ("" uses --color)
+	Level       Level     // Base log level before -v/-q are applied
+	TimeFormat  string    // Time format for log message timestamps ("" for none)
+	LogFormat   string    // LogFormatText, LogFormatJSON or LogFormatLogfmt ("" uses --log-format)
+
+	reader       *bufio.Reader // Buffered program input
+	readerSource io.Reader     // Input the buffered reader wraps
+	mu           sync.Mutex    // Serializes output while a spinner runs
+	spinner      *Spinner      // Running spinner (if any)
+	screen       *Screen       // Full-screen session (if any)
+	frame        *frameBuffer  // Output collected by Frame (if any)
+	paging       *pagerWriter  // Output sent to a pager by Page (if any)
+	historyLines int           // Lines in the history file (as far as known)
 }
 
 // -------------------------------------------
@@ -46,69 +69,78 @@ func (t *Terminal) PopIndents() *Terminal {
 // Simple, log-style output
 // -------------------------------------------
 
-// Outputs the provided message only if the program is in verbose mode
+// Outputs the provided message only if the program is in verbose mode (-v)
 func (t *Terminal) Verbose(msg string) {
-	t.printIndent()
-	fmt.Println(msg)
+	t.Log(LevelVerbose, msg)
 }
 
-// Outputs the provided message only if the program is in verbose mode
+// Outputs the provided message only if the program is in verbose mode (-v)
 func (t *Terminal) Verbosef(format string, data ...interface{}) {
-	t.printIndent()
-	fmt.Println(fmt.Sprintf(format, data...))
+	t.Logf(LevelVerbose, format, data...)
 }
 
-// Outputs the provided message
+// Outputs the provided message unless the program is quiet (-q)
 func (t *Terminal) Info(msg string) {
-	t.printIndent()
-	fmt.Println(msg)
+	t.Log(LevelInfo, msg)
 }
 
-// Outputs the provided message
+// Outputs the provided message unless the program is quiet (-q)
 func (t *Terminal) Infof(format string, data ...interface{}) {
-	t.printIndent()
-	fmt.Printf(format, data...)
-	fmt.Println()
+	t.Logf(LevelInfo, format, data...)
 }
 
 // Outputs the provided error message and exits the program with an error code
 func (t *Terminal) Fatal(msg string) {
+	t.stopSpinner()
 	// TODO pretty print the error(s) if exists
-	fmt.Fprintln(os.Stderr, msg)
-	os.Exit(1)
+	if t.Structured() {
+		t.Log(LevelError, msg)
+	} else {
+		fmt.Fprintln(t.Program.stderr(), msg)
+	}
+	t.Program.exit(1)
 }
 
 // Outputs the provided message
 func (t *Terminal) Fatalf(format string, data ...interface{}) {
-	fmt.Fprintf(os.Stderr, format, data...)
-	fmt.Fprintln(os.Stderr)
-	os.Exit(1)
+	t.Fatal(fmt.Sprintf(format, data...))
 }
 
 // Outputs the provided error message and exits the program with an error code
-// only if the provided error is non-nil. If the program is in verbose mode,
-// the error itself is dumped.
+// only if the provided error is non-nil. The error is described with the
+// errors it wraps ("caused by" lines) and the hint and documentation URL of
+// any UserError; stack details are shown at debug verbosity (-vv). The exit
+// code comes from the error (see ExitCode).
 func (t *Terminal) Error(err error, msg string) {
-	if err != nil {
-		if !reflect.ValueOf(err).IsNil() {
-			if msg != "" {
-				fmt.Fprintln(os.Stderr, msg)
+	if isNil(err) {
+		return
+	}
+	t.stopSpinner()
+	if t.Structured() {
+		attrs := []interface{}{"error", err}
+		var user *UserError
+		if errors.As(err, &user) {
+			if user.Hint != "" {
+				attrs = append(attrs, "hint", user.Hint)
+			}
+			if user.URL != "" {
+				attrs = append(attrs, "url", user.URL)
 			}
-			t.printError(err)
 		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		t.Log(LevelError, msg, attrs...)
+		t.Program.exit(ExitCode(err))
+		return
 	}
+	t.printError(err, msg)
 }
 
 // Outputs the provided message
 func (t *Terminal) Errorf(err error, format string, data ...interface{}) {
-	if err != nil {
-		if !reflect.ValueOf(err).IsNil() {
-			if format != "" {
-				fmt.Fprintf(os.Stderr, format, data...)
-				fmt.Fprintln(os.Stderr)
-			}
-			t.printError(err)
-		}
+	if !isNil(err) {
+		t.Error(err, fmt.Sprintf(format, data...))
 	}
 }
 
@@ -118,10 +150,14 @@ func (t *Terminal) Errorf(err error, format string, data ...interface{}) {
 
 // Prints characters to the screen (ignores indent and does not append nl)
 func (t *Terminal) Print(format string, data ...interface{}) *Terminal {
+	out := t.Program.stdout()
+	if t.frame != nil {
+		out = t.frame
+	}
 	if len(data) > 0 {
-		fmt.Printf(format, data...)
+		fmt.Fprintf(out, format, data...)
 	} else {
-		fmt.Print(format)
+		fmt.Fprint(out, format)
 	}
 	return t
 }
@@ -137,69 +173,337 @@ func (t *Terminal) Nl(a ...int) *Terminal {
 	return t
 }
 
+// -------------------------------------------
+// Input history
+// -------------------------------------------
+
+// Sets the file input history is persisted to
+func (t *Terminal) SetHistoryFile(file string) *Terminal {
+	t.HistoryFile = file
+	return t
+}
+
+// Loads input history from the history file (if any). A missing file is not
+// an error.
+func (t *Terminal) LoadHistory() error {
+	if t.HistoryFile == "" {
+		return nil
+	}
+	lines, err := readHistoryFile(t.HistoryFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	t.History = lines
+	t.historyLines = len(lines)
+	t.trimHistory()
+	return nil
+}
+
+// Reads the non-empty lines of a history file
+func readHistoryFile(file string) ([]string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// Adds a line to the input history and appends it to the history file (if
+// any). Empty lines and repeats of the previous line are ignored. Once the
+// file holds twice HistorySize lines it is rewritten with the latest
+// HistorySize lines, so it doesn't grow without bound.
+func (t *Terminal) AddHistory(line string) error {
+	if line == "" || (len(t.History) > 0 && t.History[len(t.History)-1] == line) {
+		return nil
+	}
+	t.History = append(t.History, line)
+	t.trimHistory()
+	if t.HistoryFile == "" {
+		return nil
+	}
+	file, err := os.OpenFile(t.HistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(file, line)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	t.historyLines++
+	if t.HistorySize > 0 && t.historyLines >= 2*t.HistorySize {
+		return t.rewriteHistory()
+	}
+	return nil
+}
+
+// Rewrites the history file with its latest HistorySize lines, replacing it
+// atomically so a failure leaves the previous file in place
+func (t *Terminal) rewriteHistory() error {
+	lines, err := readHistoryFile(t.HistoryFile)
+	if err != nil {
+		return err
+	}
+	if len(lines) > t.HistorySize {
+		lines = lines[len(lines)-t.HistorySize:]
+	}
+	temp := t.HistoryFile + ".tmp"
+	if err := ioutil.WriteFile(temp, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(temp, t.HistoryFile); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	t.historyLines = len(lines)
+	return nil
+}
+
+// Drops the oldest history lines beyond the history size
+func (t *Terminal) trimHistory() {
+	if t.HistorySize > 0 && len(t.History) > t.HistorySize {
+		t.History = t.History[len(t.History)-t.HistorySize:]
+	}
+}
+
 // -------------------------------------------
 // Cursor instructions
 // -------------------------------------------
 
 // Clears the entire screen of text and sets the cursor at the top left of the screen.
 func (t *Terminal) Clear() *Terminal {
-	return t.Print("\033[2J")
+	return t.escape("\033[2J\033[H")
 }
 
 // Clears the current line of text.
 func (t *Terminal) ClearLine() *Terminal {
-	return t.Print("\033[2K")
+	return t.escape("\033[2K")
+}
+
+// Clears from the cursor to the end of the line.
+func (t *Terminal) ClearLineEnd() *Terminal {
+	return t.escape("\033[0K")
 }
 
-// Moves cursor to the absolute coordinates x, y. Values are 1-based and default to top left corner of the screen.
+// Clears from the start of the line to the cursor.
+func (t *Terminal) ClearLineStart() *Terminal {
+	return t.escape("\033[1K")
+}
+
+// Clears from the cursor to the end of the screen.
+func (t *Terminal) ClearScreenEnd() *Terminal {
+	return t.escape("\033[0J")
+}
+
+// Clears from the top of the screen to the cursor.
+func (t *Terminal) ClearScreenStart() *Terminal {
+	return t.escape("\033[1J")
+}
+
+// Moves cursor to the absolute coordinates x (column), y (row). Values are 1-based and default to top left corner of the screen.
 func (t *Terminal) Move(x, y int) *Terminal {
-	return t.Print("\033[%d;%dH", x, y)
+	return t.escape("\033[%d;%dH", y, x)
 }
 
 // Moves cursor 'x' cells up. If the edge of the screen is reached, does nothing.
 func (t *Terminal) Up(x int) *Terminal {
-	return t.Print("\033[%dA", x)
+	return t.escape("\033[%dA", x)
 }
 
 // Moves cursor 'x' cells dwn. If the edge of the screen is reached, does nothing.
 func (t *Terminal) Down(x int) *Terminal {
-	return t.Print("\033[%dB", x)
+	return t.escape("\033[%dB", x)
 }
 
 // Moves cursor 'x' cells to the left. If the edge of the screen is reached, does nothing.
 func (t *Terminal) Left(x int) *Terminal {
-	return t.Print("\033[%dD", x)
+	return t.escape("\033[%dD", x)
 }
 
 // Moves cursor 'x' cells to the right. If the edge of the screen is reached, does nothing.
 func (t *Terminal) Right(x int) *Terminal {
-	return t.Print("\033[%dC", x)
+	return t.escape("\033[%dC", x)
 }
 
 // Move the cursor to the beginning of the line "x" lines down.
 func (t *Terminal) NextLine(x int) *Terminal {
-	return t.Print("\033[%dE", x)
+	return t.escape("\033[%dE", x)
 }
 
 // Move the cursor to the beginning of the line "x" lines up.
 func (t *Terminal) PreviousLine(x int) *Terminal {
-	return t.Print("\033[%dF", x)
+	return t.escape("\033[%dF", x)
 }
 
 // Hide the cursor
 func (t *Terminal) Hide() *Terminal {
-	return t.Print("\033[?25h")
+	return t.escape("\033[?25l")
 }
 
 // Show the cursor
 func (t *Terminal) Show() *Terminal {
-	return t.Print("\033[?25l")
+	return t.escape("\033[?25h")
+}
+
+// Saves the cursor position (and style) to be restored with RestoreCursor.
+func (t *Terminal) SaveCursor() *Terminal {
+	return t.escape("\0337")
+}
+
+// Returns the cursor to the position saved by SaveCursor.
+func (t *Terminal) RestoreCursor() *Terminal {
+	return t.escape("\0338")
+}
+
+// Limits scrolling to the rows from top to bottom (1-based, inclusive), so
+// the lines outside the region stay in place.
+func (t *Terminal) SetScrollRegion(top, bottom int) *Terminal {
+	return t.escape("\033[%d;%dr", top, bottom)
+}
+
+// Restores scrolling of the whole screen.
+func (t *Terminal) ResetScrollRegion() *Terminal {
+	return t.escape("\033[r")
+}
+
+// Scrolls the screen (or scroll region) contents 'x' lines up.
+func (t *Terminal) ScrollUp(x int) *Terminal {
+	return t.escape("\033[%dS", x)
+}
+
+// Scrolls the screen (or scroll region) contents 'x' lines down.
+func (t *Terminal) ScrollDown(x int) *Terminal {
+	return t.escape("\033[%dT", x)
+}
+
+// CursorReportTimeout is how long CursorPosition waits for the terminal to
+// answer.
+var CursorReportTimeout = time.Second
+
+// Asks the terminal for the cursor position, returning the 1-based column
+// and row. The program input and output must be a terminal that answers
+// position reports within CursorReportTimeout (ErrNoCursorReport otherwise).
+// Keys typed before the answer are kept for the next read. Inside a Frame,
+// the output drawn so far is written first so the position reflects it.
+func (t *Terminal) CursorPosition() (x, y int, err error) {
+	out := t.Program.stdout()
+	fd, ok := t.inputTerminal()
+	if !ok || !writerIsTerminal(out) {
+		return 0, 0, ErrNotInteractive
+	}
+	t.mu.Lock()
+	screen, frame := t.screen, t.frame
+	t.mu.Unlock()
+	if screen == nil {
+		state, err := makeRaw(fd)
+		if err != nil {
+			return 0, 0, err
+		}
+		defer restoreTerminal(fd, state)
+	}
+	// The request would otherwise wait in the frame until the answer times out
+	request := []byte("\033[6n")
+	if frame != nil {
+		request = append(frame.take(), request...)
+	}
+	t.mu.Lock()
+	_, err = out.Write(request)
+	t.mu.Unlock()
+	if err != nil {
+		return 0, 0, err
+	}
+	deadline := time.Now().Add(CursorReportTimeout)
+	x, y, pending, err := readCursorReport(t.inputReader(), func() bool {
+		return waitInput(fd, time.Until(deadline))
+	})
+	t.unread(pending)
+	return x, y, err
+}
+
+// unread puts `data` back in front of the program input.
+func (t *Terminal) unread(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	in := t.inputReader()
+	buffered, _ := in.Peek(in.Buffered())
+	data = append(append([]byte(nil), data...), buffered...)
+	t.reader = bufio.NewReader(io.MultiReader(bytes.NewReader(data), t.readerSource))
+}
+
+// Collects the output written by `draw` and writes it at once, so a screen
+// update made of many escape sequences is displayed without flicker.
+func (t *Terminal) Frame(draw func()) error {
+	if t.frame != nil {
+		// Already in a frame
+		draw()
+		return nil
+	}
+	frame := &frameBuffer{}
+	func() {
+		t.setFrame(frame)
+		defer t.setFrame(nil)
+		draw()
+	}()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := t.Program.stdout().Write(frame.Bytes())
+	return err
+}
+
+// setFrame sets the buffer collecting Frame output. The spinner goroutine
+// reads it with the lock held.
+func (t *Terminal) setFrame(frame *frameBuffer) {
+	t.mu.Lock()
+	t.frame = frame
+	t.mu.Unlock()
+}
+
+// frameBuffer collects the output of a Frame, which may also be written by
+// the spinner goroutine.
+type frameBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *frameBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.Write(p)
+}
+
+// take returns the collected output and empties the buffer.
+func (b *frameBuffer) take() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data := append([]byte(nil), b.buffer.Bytes()...)
+	b.buffer.Reset()
+	return data
+}
+
+// Bytes returns the collected output.
+func (b *frameBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.Bytes()
 }
 
 // -------------------------------------------
 // Color
 // -------------------------------------------
 
+// Basic colors for Color, Fg and Bg
 const (
 	Black = iota
 	Red
@@ -211,31 +515,55 @@ const (
 	White
 )
 
+// Sets the foreground and background to one of the basic colors (Black through White).
 func (t *Terminal) Color(foreground, background int) *Terminal {
-	return t.Print("\033[3%dm;4%dm;", foreground, background)
+	return t.Style(Fg(foreground), Bg(background))
+}
+
+// Turns on the combination of the provided styles for following output.
+func (t *Terminal) Style(styles ...Style) *Terminal {
+	if !t.ColorEnabled() {
+		return t
+	}
+	return t.Print(NewStyle(styles...).Sequence())
+}
+
+// Prints the text in the combination of the provided styles, resetting afterwards.
+func (t *Terminal) Styled(text string, styles ...Style) *Terminal {
+	return t.Print(t.Paint(text, styles...))
 }
 
 // Reset terminal attributes (including colors) to default values.
 func (t *Terminal) Reset() *Terminal {
-	return t.Print("\033[0m")
+	if !t.ColorEnabled() {
+		return t
+	}
+	return t.Print(resetSequence)
 }
 
 // -------------------------------------------
 // Helpers
 // -------------------------------------------
 
-// Prints stdout indents if necessary
-func (t *Terminal) printIndent() {
-	if t.Indent > 0 {
-		fmt.Print(strings.Repeat(" ", (int)(t.Indent*t.IndentSize)))
+// Prints an escape sequence when cursor control is enabled
+func (t *Terminal) escape(format string, data ...interface{}) *Terminal {
+	if !t.CursorEnabled() {
+		return t
 	}
+	return t.Print(format, data...)
 }
 
-// Pretty prints the error in error messages
-func (t *Terminal) printError(err error) {
-	// if p.verbose {
-	fmt.Fprintf(os.Stderr, "\nError: %#v\n", err)
-	// }
-	// TODO if err has an error code, use that for the exit code
-	os.Exit(1)
+// Returns the spaces for the current indent level
+func (t *Terminal) indentation() string {
+	return strings.Repeat(" ", (int)(t.Indent*t.IndentSize))
+}
+
+// Pretty prints the error (after `msg` if set) and exits with its exit code
+func (t *Terminal) printError(err error, msg string) {
+	text := t.formatError(err)
+	if msg != "" {
+		text = msg + "\n  " + strings.Replace(text, "\n", "\n  ", -1)
+	}
+	t.Log(LevelError, text)
+	t.Program.exit(ExitCode(err))
 }
@@ -0,0 +1,156 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Tree is a node in a tree of labels drawn with connecting lines. Create the
+// root with Terminal.Tree, add children with Add (which returns the child, so
+// trees can be built during a recursive walk) and output it with Print.
+// Display settings are taken from the root node.
+type Tree struct {
+	Terminal *Terminal
+	Label    string
+	Style    Style // Label style, applied when color is enabled
+	Children []*Tree
+	Border   Border // BorderUnicode (default) or BorderASCII connectors, BorderNone for indentation only
+	MaxDepth int    // Depth below which children are collapsed (0 for no limit)
+
+	root *Tree
+}
+
+// treeConnectors are the prefixes drawn before a child: middle child, last
+// child, then the continuation below a middle and a last child.
+type treeConnectors struct {
+	middle, last, line, space string
+}
+
+var connectors = map[Border]treeConnectors{
+	BorderNone:    {middle: "  ", last: "  ", line: "  ", space: "  "},
+	BorderASCII:   {middle: "|-- ", last: "`-- ", line: "|   ", space: "    "},
+	BorderUnicode: {middle: "├── ", last: "└── ", line: "│   ", space: "    "},
+}
+
+// Creates the root node of a tree.
+func (t *Terminal) Tree(label string, styles ...Style) *Tree {
+	tree := &Tree{Terminal: t, Label: label, Style: NewStyle(styles...), Border: BorderUnicode}
+	tree.root = tree
+	return tree
+}
+
+// Add appends a child node and returns it.
+func (n *Tree) Add(label string, styles ...Style) *Tree {
+	child := &Tree{Terminal: n.Terminal, Label: label, Style: NewStyle(styles...), root: n.root}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// Sets the connector style (BorderUnicode, BorderASCII or BorderNone).
+func (n *Tree) SetBorder(border Border) *Tree {
+	n.root.Border = border
+	return n
+}
+
+// Collapses nodes deeper than `depth` levels below the root; a collapsed
+// node shows the number of hidden descendants.
+func (n *Tree) SetMaxDepth(depth int) *Tree {
+	n.root.MaxDepth = depth
+	return n
+}
+
+// Print renders the tree to the program output.
+func (n *Tree) Print() error {
+	_, err := io.WriteString(n.Terminal.Program.stdout(), n.String())
+	return err
+}
+
+// String renders the tree with one node per line.
+func (n *Tree) String() string {
+	var b strings.Builder
+	n.render(&b, "", 0)
+	return b.String()
+}
+
+// render writes the node label and its children. `prefix` holds the
+// continuation lines of the ancestors.
+func (n *Tree) render(b *strings.Builder, prefix string, depth int) {
+	root := n.root
+	if root == nil {
+		root = n
+	}
+	color := n.Terminal.ColorEnabled()
+	label := n.Label
+	if color && !n.Style.IsZero() {
+		label = n.Style.Wrap(label)
+	}
+	collapsed := root.MaxDepth > 0 && depth >= root.MaxDepth && len(n.Children) > 0
+	if collapsed {
+		hidden := fmt.Sprintf(" [+%d]", n.descendants())
+		if color {
+			hidden = Dim.Wrap(hidden)
+		}
+		label += hidden
+	}
+	b.WriteString(label + "\n")
+	if collapsed {
+		return
+	}
+	joins, ok := connectors[root.Border]
+	if !ok {
+		joins = connectors[BorderUnicode]
+	}
+	for i, child := range n.Children {
+		if i == len(n.Children)-1 {
+			b.WriteString(prefix + joins.last)
+			child.render(b, prefix+joins.space, depth+1)
+		} else {
+			b.WriteString(prefix + joins.middle)
+			child.render(b, prefix+joins.line, depth+1)
+		}
+	}
+}
+
+// descendants counts the nodes below this one.
+func (n *Tree) descendants() int {
+	count := len(n.Children)
+	for _, child := range n.Children {
+		count += child.descendants()
+	}
+	return count
+}
+
+// CommandTree returns a tree of the program commands with their options and
+// the help topics (used by `help --tree`).
+func (p *Program) CommandTree() *Tree {
+	tree := p.Terminal.Tree(p.Exe, Bold)
+	var names []string
+	for name := range p.Commands {
+		if name != "*" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		command := p.Commands[name]
+		node := tree.Add(strings.TrimSpace(p.Terminal.Paint(command.Flags, Bold) + "  " + command.Description))
+		for _, option := range command.Options {
+			node.Add(strings.TrimSpace(option.Flags + "  " + option.Description))
+		}
+	}
+	if len(p.Topics) > 0 {
+		topics := tree.Add(p.Terminal.Paint("topics", Bold))
+		names = names[:0]
+		for name := range p.Topics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			topics.Add(strings.TrimSpace(name + "  " + p.Topics[name].Description))
+		}
+	}
+	return tree
+}
@@ -0,0 +1,63 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+package cli_test
+
+import (
+	"bytes"
+
+	. "github.com/gopackage/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Trees", func() {
+
+	var program *Program
+	var out bytes.Buffer
+	var tree *Tree
+
+	BeforeEach(func() {
+		out.Reset()
+		program = New()
+		program.Out = &out
+		tree = program.Terminal.Tree("app")
+		lib := tree.Add("lib")
+		lib.Add("util").Add("strings")
+		lib.Add("net")
+		tree.Add("main.go", Fg(Green))
+	})
+
+	It("should draw Unicode connectors", func() {
+		Ω(tree.String()).Should(Equal("" +
+			"app\n" +
+			"├── lib\n" +
+			"│   ├── util\n" +
+			"│   │   └── strings\n" +
+			"│   └── net\n" +
+			"└── main.go\n"))
+	})
+	It("should draw ASCII connectors and collapse deep nodes", func() {
+		Ω(tree.SetBorder(BorderASCII).SetMaxDepth(1).String()).Should(Equal("" +
+			"app\n" +
+			"|-- lib [+3]\n" +
+			"`-- main.go\n"))
+	})
+	It("should color labels when color is enabled", func() {
+		program.Terminal.SetColorMode(ColorAlways)
+		Ω(tree.Print()).Should(Succeed())
+		Ω(out.String()).Should(ContainSubstring("└── \033[32mmain.go\033[0m\n"))
+	})
+	It("should display the command tree with help --tree", func() {
+		program.Command("tcp <port>", "capture TCP packets").Option("-H, --host <host>", "host to capture")
+		program.Topic("filters", "writing capture filters")
+		program.ParseArgs([]string{"exe", "help", "--tree"})
+		Ω(out.String()).Should(Equal("" +
+			"exe\n" +
+			"├── help [cmd]  display help for [cmd]\n" +
+			"│   ├── --tree  display the commands, options and topics as a tree\n" +
+			"│   └── -s, --search <term>  search commands, options and topics for <term>\n" +
+			"├── tcp <port>  capture TCP packets\n" +
+			"│   └── -H, --host <host>  host to capture\n" +
+			"└── topics\n" +
+			"    └── filters  writing capture filters\n"))
+	})
+})
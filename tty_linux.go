@@ -0,0 +1,144 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+
+//go:build linux
+// +build linux
+
+package cli
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// resizeSignals are the signals sent when the terminal is resized.
+var resizeSignals = []os.Signal{syscall.SIGWINCH}
+
+// screenSignals are the signals that restore the terminal and exit during a
+// full-screen session.
+var screenSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+
+// signalExitCode returns the conventional exit status (128 + signal number)
+// for a program killed by `sig`.
+func signalExitCode(sig os.Signal) int {
+	if n, ok := sig.(syscall.Signal); ok {
+		return 128 + int(n)
+	}
+	return 1
+}
+
+// ttyState holds terminal settings so they can be restored.
+type ttyState struct {
+	termios syscall.Termios
+}
+
+// getTermios reads the terminal settings of `fd`.
+func getTermios(fd int) (*syscall.Termios, error) {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	if errno != 0 {
+		return nil, errno
+	}
+	return &termios, nil
+}
+
+// setTermios applies terminal settings to `fd`.
+func setTermios(fd int, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TCSETS, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// isTerminal returns true if `fd` is a terminal.
+func isTerminal(fd int) bool {
+	_, err := getTermios(fd)
+	return err == nil
+}
+
+// makeRaw puts `fd` into raw mode (no line buffering, echo or signal keys)
+// and returns the previous state. Output processing is left on so newlines
+// written while in raw mode still return the carriage.
+func makeRaw(fd int) (*ttyState, error) {
+	termios, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	state := &ttyState{termios: *termios}
+
+	termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Cflag &^= syscall.CSIZE | syscall.PARENB
+	termios.Cflag |= syscall.CS8
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+	if err := setTermios(fd, termios); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// disableEcho turns off input echo on `fd` (for password entry) and returns
+// the previous state.
+func disableEcho(fd int) (*ttyState, error) {
+	termios, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	state := &ttyState{termios: *termios}
+	termios.Lflag &^= syscall.ECHO
+	termios.Lflag |= syscall.ICANON | syscall.ISIG
+	termios.Iflag |= syscall.ICRNL
+	if err := setTermios(fd, termios); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// restoreTerminal returns `fd` to a previously saved state.
+func restoreTerminal(fd int, state *ttyState) error {
+	return setTermios(fd, &state.termios)
+}
+
+// windowSize returns the size of the terminal `fd` in columns and rows.
+func windowSize(fd int) (int, int, error) {
+	var size struct {
+		rows, cols, xpixel, ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0, 0, errno
+	}
+	return int(size.cols), int(size.rows), nil
+}
+
+// pollFd is the `struct pollfd` argument of ppoll.
+type pollFd struct {
+	fd      int32
+	events  int16
+	revents int16
+}
+
+// pollIn is the ppoll event for readable input.
+const pollIn = 0x1
+
+// waitInput returns true when input can be read from `fd` within `timeout`.
+func waitInput(fd int, timeout time.Duration) bool {
+	for {
+		if timeout < 0 {
+			timeout = 0
+		}
+		poll := pollFd{fd: int32(fd), events: pollIn}
+		ts := syscall.NsecToTimespec(timeout.Nanoseconds())
+		start := time.Now()
+		n, _, errno := syscall.Syscall6(syscall.SYS_PPOLL, uintptr(unsafe.Pointer(&poll)), 1,
+			uintptr(unsafe.Pointer(&ts)), 0, 0, 0)
+		if errno == syscall.EINTR {
+			timeout -= time.Since(start)
+			continue
+		}
+		return errno == 0 && n > 0
+	}
+}
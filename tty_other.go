@@ -0,0 +1,60 @@
+// 2014 Iain Shigeoka - BSD license (see LICENSE)
+
+//go:build !linux
+// +build !linux
+
+package cli
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// errNoTTY is returned when terminal control isn't supported on the platform.
+var errNoTTY = errors.New("terminal control not supported on this platform")
+
+// resizeSignals are the signals sent when the terminal is resized (none).
+var resizeSignals []os.Signal
+
+// screenSignals are the signals that restore the terminal and exit during a
+// full-screen session.
+var screenSignals = []os.Signal{os.Interrupt}
+
+// signalExitCode returns the exit status for a program killed by `sig`.
+func signalExitCode(sig os.Signal) int {
+	return 1
+}
+
+// ttyState holds terminal settings so they can be restored.
+type ttyState struct{}
+
+// isTerminal returns true if `fd` is a terminal.
+func isTerminal(fd int) bool {
+	return false
+}
+
+// makeRaw puts `fd` into raw mode and returns the previous state.
+func makeRaw(fd int) (*ttyState, error) {
+	return nil, errNoTTY
+}
+
+// disableEcho turns off input echo on `fd` and returns the previous state.
+func disableEcho(fd int) (*ttyState, error) {
+	return nil, errNoTTY
+}
+
+// restoreTerminal returns `fd` to a previously saved state.
+func restoreTerminal(fd int, state *ttyState) error {
+	return errNoTTY
+}
+
+// windowSize returns the size of the terminal `fd` in columns and rows.
+func windowSize(fd int) (int, int, error) {
+	return 0, 0, errNoTTY
+}
+
+// waitInput returns true when input can be read from `fd` within `timeout`.
+func waitInput(fd int, timeout time.Duration) bool {
+	return false
+}